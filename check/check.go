@@ -1,20 +1,16 @@
 package check
 
 import (
-	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"time"
 
-	"github.com/go-git/go-billy/v5/memfs"
-	"github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-git/go-git/v5/plumbing/object"
-	"github.com/go-git/go-git/v5/storage/memory"
+	timeMachine "github.com/benbjohnson/clock"
+	"github.com/go-git/go-billy/v5"
 	"github.com/go-playground/validator/v10"
 	"github.com/homeport/freeze-calendar-resource/resource"
-	"golang.org/x/exp/slices"
 )
 
 type Request struct {
@@ -24,6 +20,10 @@ type Request struct {
 
 type Response []resource.Version
 
+type ContextKey string
+
+const ContextKeyClock = ContextKey("clock")
+
 // Request:
 //
 //	{
@@ -39,9 +39,15 @@ type Response []resource.Version
 // Response:
 //
 // [{ "version": { "sha": "..." } }]
+//
+// A source with a url instead of a uri has no history; its only version is the current ETag of the calendar (or a hash
+// of its content), e.g. [{ "etag": "\"33a64df5\"" }].
+//
+// With boundaries configured in the source, the latest version also carries the time of the last window boundary,
+// e.g. { "sha": "...", "boundary": "2023-12-27T06:00:00Z" }, so that a new version is emitted when a freeze starts or ends.
 func Check(ctx context.Context, req io.Reader, resp, log io.Writer) error {
 	var request Request
-	err := json.NewDecoder(req).Decode(&request)
+	err := resource.DecodeRequest(req, &request)
 
 	if err != nil {
 		return fmt.Errorf("unable to decode request: %w", err)
@@ -53,68 +59,63 @@ func Check(ctx context.Context, req io.Reader, resp, log io.Writer) error {
 		return fmt.Errorf("request validation failed: %w", err)
 	}
 
-	auth, err := request.Source.Auth()
+	fetcher, err := request.Source.Fetcher(log)
 
 	if err != nil {
-		return fmt.Errorf("unable to build authenticator: %w", err)
+		return err
 	}
 
-	fs := memfs.New()
-
-	repo, err := git.Clone(memory.NewStorage(), fs, &git.CloneOptions{
-		URL:           request.Source.URI,
-		ReferenceName: plumbing.ReferenceName(request.Source.Branch),
-		Auth:          auth,
-		Progress:      log,
-	})
+	response, fs, err := fetcher.Versions(ctx, request.Version)
 
 	if err != nil {
-		return fmt.Errorf("unable to clone: %w", err)
+		return err
 	}
 
-	cIter, err := repo.Log(&git.LogOptions{
-		PathFilter: func(s string) bool {
-			return s == request.Source.Path
-		},
-		Order: git.LogOrderCommitterTime,
-	})
+	if request.Source.Boundaries != nil && len(response) > 0 {
+		boundary, err := lastBoundary(ctx, fs, request.Source)
 
-	if err != nil {
-		return fmt.Errorf("could not log the history: %w", err)
-	}
+		if err != nil {
+			return err
+		}
+
+		latest := &response[len(response)-1]
+
+		if latest.Same(request.Version) {
+			// the requested version must come first, unchanged; the same calendar at a new boundary is a new version
+			*latest = request.Version
 
-	// "The list may be empty, if there are no versions available at the source."
-	// TODO When would that happen? If the repo or branch doesn't exist?
+			if latest.Boundary != boundary {
+				next := *latest
+				next.Boundary = boundary
+				response = append(response, next)
+			}
+		} else {
+			latest.Boundary = boundary
+		}
+	}
 
-	var response []resource.Version
+	return json.NewEncoder(resp).Encode(response)
+}
 
-	err = cIter.ForEach(func(commit *object.Commit) error {
-		sha := commit.Hash.String()
-		response = append(response, resource.Version{SHA: sha})
-		return nil
-	})
+// lastBoundary returns the last boundary of the calendar in fs as of now, or an empty string if there is none yet
+func lastBoundary(ctx context.Context, fs billy.Filesystem, source resource.Source) (string, error) {
+	calendar, err := source.LoadCalendar(fs, resource.NewGitFetcher(ctx, source))
 
 	if err != nil {
-		return fmt.Errorf("could not iterate over commits: %w", err)
+		return "", fmt.Errorf("unable to load calendar: %w", err)
 	}
 
-	// "... must print the array of new versions, in chronological order (oldest first)"
-	// from https://concourse-ci.org/implementing-resource-types.html#resource-check
-	slices.Reverse(response)
+	var clock timeMachine.Clock = timeMachine.New()
 
-	// If a version is provided in the request, return only versions newer than the requested one
-	if request.Version.SHA != "" {
-		i, found := slices.BinarySearchFunc(response, resource.Version{SHA: request.Version.SHA}, func(a, b resource.Version) int {
-			return cmp.Compare(a.SHA, b.SHA)
-		})
+	if value := ctx.Value(ContextKeyClock); value != nil {
+		clock = value.(timeMachine.Clock)
+	}
 
-		if found {
-			response = response[i:]
-		} else {
-			// "If your resource is unable to determine which versions are newer than the given version (e.g. if it's a git commit that was push -fed over), then the current version of your resource should be returned (i.e. the new HEAD)."
-			response = []resource.Version{response[len(response)-1]}
-		}
+	boundary, found := calendar.LastBoundary(clock.Now(), source.Boundaries.Scope, source.Boundaries.Runway.Duration)
+
+	if !found {
+		return "", nil
 	}
 
-	return json.NewEncoder(resp).Encode(response)
+	return boundary.UTC().Format(time.RFC3339), nil
 }
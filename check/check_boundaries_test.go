@@ -0,0 +1,127 @@
+package check_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	timeMachine "github.com/benbjohnson/clock"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/homeport/freeze-calendar-resource/check"
+	"github.com/homeport/freeze-calendar-resource/resource"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Check with boundaries", func() {
+	var (
+		err      error
+		origin   string
+		head     plumbing.Hash
+		version  string
+		clock    *timeMachine.Mock
+		response check.Response
+	)
+
+	BeforeEach(func() {
+		origin = path.Join(GinkgoT().TempDir(), "remote")
+		clock = timeMachine.NewMock()
+		clock.Set(time.Date(2023, time.October, 11, 12, 0, 0, 0, time.UTC))
+		version = "null"
+
+		repo, err := git.PlainInitWithOptions(origin, &git.PlainInitOptions{
+			InitOptions: git.InitOptions{DefaultBranch: plumbing.Main},
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		worktree, err := repo.Worktree()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		f, err := worktree.Filesystem.Create("calendar.yaml")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, err = f.Write([]byte(`
+freeze_calendar:
+  - name: Summer Break
+    starts_at: 2023-07-01T00:00:00Z
+    ends_at: 2023-08-01T00:00:00Z
+  - name: Oktoberfest
+    starts_at: 2023-10-11T16:00:00Z
+    ends_at: 2023-10-12T00:00:00Z
+    scope: [eu-de]
+`))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(f.Close()).To(Succeed())
+
+		_, err = worktree.Add("calendar.yaml")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		head, err = worktree.Commit("Create freeze calendar", &git.CommitOptions{
+			Author: &object.Signature{Name: "Testbild Tester", Email: "testbild.tester@example.org", When: time.Now()},
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	JustBeforeEach(func(ctx SpecContext) {
+		req := strings.NewReader(fmt.Sprintf(`{
+			"source": {
+				"uri": "%s",
+				"path": "calendar.yaml",
+				"boundaries": { "scope": ["eu-de"], "runway": "30m" }
+			},
+			"version": %s
+		}`, origin, version))
+
+		var resp, log strings.Builder
+		err = check.Check(context.WithValue(ctx, check.ContextKeyClock, clock), req, &resp, &log)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		response = nil
+		Expect(json.NewDecoder(strings.NewReader(resp.String())).Decode(&response)).To(Succeed())
+	})
+
+	It("adds the last boundary to the latest version", func() {
+		Expect(response).To(Equal(check.Response{{SHA: head.String(), Boundary: "2023-07-31T23:30:00Z"}}))
+	})
+
+	Context("with the same boundary requested", func() {
+		BeforeEach(func() {
+			version = fmt.Sprintf(`{ "sha": "%s", "boundary": "2023-07-31T23:30:00Z" }`, head)
+		})
+
+		It("emits no new version", func() {
+			Expect(response).To(Equal(check.Response{{SHA: head.String(), Boundary: "2023-07-31T23:30:00Z"}}))
+		})
+	})
+
+	Context("once a window within scope becomes active", func() {
+		BeforeEach(func() {
+			version = fmt.Sprintf(`{ "sha": "%s", "boundary": "2023-07-31T23:30:00Z" }`, head)
+			clock.Set(time.Date(2023, time.October, 11, 15, 45, 0, 0, time.UTC))
+		})
+
+		It("emits a new version of the same commit", func() {
+			Expect(response).To(Equal(check.Response{
+				{SHA: head.String(), Boundary: "2023-07-31T23:30:00Z"},
+				{SHA: head.String(), Boundary: "2023-10-11T15:30:00Z"},
+			}))
+		})
+	})
+
+	Context("once the window has ended", func() {
+		BeforeEach(func() {
+			version = fmt.Sprintf(`{ "sha": "%s", "boundary": "2023-10-11T15:30:00Z" }`, head)
+			clock.Set(time.Date(2023, time.October, 12, 8, 0, 0, 0, time.UTC))
+		})
+
+		It("emits a new version of the same commit", func() {
+			Expect(response[len(response)-1]).To(Equal(resource.Version{SHA: head.String(), Boundary: "2023-10-11T23:30:00Z"}))
+		})
+	})
+})
@@ -0,0 +1,263 @@
+package check_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/homeport/freeze-calendar-resource/check"
+	"github.com/homeport/freeze-calendar-resource/resource"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Check walking the history", func() {
+	var (
+		err      error
+		origin   string
+		repo     *git.Repository
+		worktree *git.Worktree
+		when     time.Time
+		version  string
+		cacheDir string
+		response check.Response
+	)
+
+	// commit writes the given files and commits them on the current branch; with parents, it is a merge
+	commit := func(files map[string]string, parents ...plumbing.Hash) plumbing.Hash {
+		for name, content := range files {
+			Expect(util.WriteFile(worktree.Filesystem, name, []byte(content), 0o644)).To(Succeed())
+			_, err := worktree.Add(name)
+			Expect(err).ShouldNot(HaveOccurred())
+		}
+
+		when = when.Add(time.Minute)
+		hash, err := worktree.Commit(fmt.Sprintf("Commit at %s", when), &git.CommitOptions{
+			Author:  &object.Signature{Name: "Testbild Tester", Email: "testbild.tester@example.org", When: when},
+			Parents: parents,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		return hash
+	}
+
+	switchTo := func(branch string, create bool) {
+		Expect(worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(branch), Create: create})).To(Succeed())
+	}
+
+	resetTo := func(hash plumbing.Hash) {
+		Expect(worktree.Reset(&git.ResetOptions{Commit: hash, Mode: git.HardReset})).To(Succeed())
+	}
+
+	versionOf := func(hash plumbing.Hash) string {
+		return fmt.Sprintf(`{ "sha": "%s" }`, hash)
+	}
+
+	shas := func(hashes ...plumbing.Hash) check.Response {
+		var versions check.Response
+
+		for _, hash := range hashes {
+			versions = append(versions, resource.Version{SHA: hash.String()})
+		}
+
+		return versions
+	}
+
+	BeforeEach(func() {
+		origin = path.Join(GinkgoT().TempDir(), "remote")
+		when = time.Date(2023, time.October, 1, 12, 0, 0, 0, time.UTC)
+		version = "null"
+		cacheDir = ""
+
+		repo, err = git.PlainInitWithOptions(origin, &git.PlainInitOptions{
+			InitOptions: git.InitOptions{DefaultBranch: plumbing.Main},
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		worktree, err = repo.Worktree()
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	JustBeforeEach(func(ctx SpecContext) {
+		req := strings.NewReader(fmt.Sprintf(`{
+			"source": { "uri": "%s", "path": "calendar.yaml", "cache_dir": "%s" },
+			"version": %s
+		}`, origin, cacheDir, version))
+
+		var resp strings.Builder
+		err = check.Check(ctx, req, &resp, GinkgoWriter)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		response = nil
+		Expect(json.Unmarshal([]byte(resp.String()), &response)).To(Succeed())
+	})
+
+	Context("with a linear history", func() {
+		var first, unrelated, second plumbing.Hash
+
+		BeforeEach(func() {
+			first = commit(map[string]string{"calendar.yaml": "freeze_calendar: []\n"})
+			unrelated = commit(map[string]string{"README.md": "# Freeze Calendar\n"})
+			second = commit(map[string]string{"calendar.yaml": "freeze_calendar: [] # changed\n"})
+		})
+
+		It("emits all commits that changed the calendar", func() {
+			Expect(response).To(Equal(shas(first, second)))
+		})
+
+		Context("requesting the first version", func() {
+			BeforeEach(func() {
+				version = versionOf(first)
+			})
+
+			It("emits it and the newer ones", func() {
+				Expect(response).To(Equal(shas(first, second)))
+			})
+		})
+
+		Context("requesting a commit that did not change the calendar", func() {
+			BeforeEach(func() {
+				version = versionOf(unrelated)
+			})
+
+			It("emits it and the newer ones", func() {
+				Expect(response).To(Equal(shas(unrelated, second)))
+			})
+		})
+
+		Context("requesting the latest version", func() {
+			BeforeEach(func() {
+				version = versionOf(second)
+			})
+
+			It("emits just that", func() {
+				Expect(response).To(Equal(shas(second)))
+			})
+		})
+
+		Context("requesting a version that does not exist", func() {
+			BeforeEach(func() {
+				version = `{ "sha": "0000000000000000000000000000000000000000" }`
+			})
+
+			It("emits just the latest version", func() {
+				Expect(response).To(Equal(shas(second)))
+			})
+		})
+	})
+
+	Context("with merges", func() {
+		var base, featureChange, mainUnrelated, merge plumbing.Hash
+
+		BeforeEach(func() {
+			base = commit(map[string]string{"calendar.yaml": "freeze_calendar: []\n"})
+
+			switchTo("feature", true)
+			featureChange = commit(map[string]string{"calendar.yaml": "freeze_calendar: [] # feature\n"})
+
+			switchTo("main", false)
+			mainUnrelated = commit(map[string]string{"README.md": "# Freeze Calendar\n"})
+			merge = commit(map[string]string{"calendar.yaml": "freeze_calendar: [] # feature\n"}, mainUnrelated, featureChange)
+
+			version = versionOf(base)
+		})
+
+		It("attributes the change to the merged commit", func() {
+			Expect(response).To(Equal(shas(base, featureChange)))
+		})
+
+		Context("resolving a conflict", func() {
+			var mainChange, resolution plumbing.Hash
+
+			BeforeEach(func() {
+				version = versionOf(merge)
+
+				switchTo("feature", false)
+				featureChange = commit(map[string]string{"calendar.yaml": "freeze_calendar: [] # feature, again\n"})
+
+				switchTo("main", false)
+				mainChange = commit(map[string]string{"calendar.yaml": "freeze_calendar: [] # main\n"})
+				resolution = commit(map[string]string{"calendar.yaml": "freeze_calendar: [] # both\n"}, mainChange, featureChange)
+			})
+
+			It("emits the changes of both branches and the merge, oldest first", func() {
+				Expect(response).To(Equal(shas(merge, featureChange, mainChange, resolution)))
+			})
+		})
+
+		Context("with skewed clocks", func() {
+			var early plumbing.Hash
+
+			BeforeEach(func() {
+				version = versionOf(merge)
+
+				when = when.Add(-24 * time.Hour)
+				early = commit(map[string]string{"calendar.yaml": "freeze_calendar: [] # from the past\n"})
+			})
+
+			It("still puts parents first", func() {
+				Expect(response).To(Equal(shas(merge, early)))
+			})
+		})
+	})
+
+	Context("with a rebase", func() {
+		var base, rebased, replacement plumbing.Hash
+
+		BeforeEach(func() {
+			base = commit(map[string]string{"calendar.yaml": "freeze_calendar: []\n"})
+			rebased = commit(map[string]string{"calendar.yaml": "freeze_calendar: [] # before rebase\n"})
+
+			resetTo(base)
+			commit(map[string]string{"README.md": "# Freeze Calendar\n"})
+			replacement = commit(map[string]string{"calendar.yaml": "freeze_calendar: [] # after rebase\n"})
+
+			version = versionOf(rebased)
+		})
+
+		It("emits just the latest version", func() {
+			Expect(response).To(Equal(shas(replacement)))
+		})
+
+		Context("with a cache that still has the rebased commit", func() {
+			BeforeEach(func() {
+				cacheDir = GinkgoT().TempDir()
+
+				// fill the cache before the rebase
+				resetTo(rebased)
+				Expect(check.Check(context.Background(), strings.NewReader(fmt.Sprintf(`{
+					"source": { "uri": "%s", "path": "calendar.yaml", "cache_dir": "%s" }
+				}`, origin, cacheDir)), &strings.Builder{}, GinkgoWriter)).To(Succeed())
+				resetTo(replacement)
+			})
+
+			It("emits just the latest version", func() {
+				Expect(response).To(Equal(shas(replacement)))
+			})
+		})
+	})
+
+	Context("with a force-push to an older commit", func() {
+		var first, second plumbing.Hash
+
+		BeforeEach(func() {
+			first = commit(map[string]string{"calendar.yaml": "freeze_calendar: []\n"})
+			second = commit(map[string]string{"calendar.yaml": "freeze_calendar: [] # changed\n"})
+			resetTo(first)
+
+			version = versionOf(second)
+		})
+
+		It("emits just the latest version", func() {
+			Expect(response).To(Equal(shas(first)))
+		})
+	})
+})
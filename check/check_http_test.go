@@ -0,0 +1,113 @@
+package check_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	timeMachine "github.com/benbjohnson/clock"
+
+	"github.com/homeport/freeze-calendar-resource/check"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Check with an HTTP source", func() {
+	var (
+		err        error
+		server     *httptest.Server
+		etag       string
+		version    string
+		boundaries string
+		response   check.Response
+	)
+
+	BeforeEach(func() {
+		etag = `"v2"`
+		version = "null"
+		boundaries = "null"
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", etag)
+			_, _ = w.Write([]byte(`
+freeze_calendar:
+  - name: Oktoberfest
+    starts_at: 2023-10-11T16:00:00Z
+    ends_at: 2023-10-12T00:00:00Z
+`))
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	JustBeforeEach(func(ctx SpecContext) {
+		clock := timeMachine.NewMock()
+		clock.Set(time.Date(2023, time.October, 11, 17, 0, 0, 0, time.UTC))
+
+		req := strings.NewReader(fmt.Sprintf(`{
+			"source": { "url": "%s/freeze-calendar.yaml", "boundaries": %s },
+			"version": %s
+		}`, server.URL, boundaries, version))
+
+		var resp strings.Builder
+		err = check.Check(context.WithValue(ctx, check.ContextKeyClock, clock), req, &resp, GinkgoWriter)
+
+		response = nil
+
+		if err == nil {
+			Expect(json.Unmarshal([]byte(resp.String()), &response)).To(Succeed())
+		}
+	})
+
+	It("emits the ETag as version", func() {
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(response).To(Equal(check.Response{{ETag: `"v2"`}}))
+	})
+
+	Context("requesting the current version", func() {
+		BeforeEach(func() {
+			version = `{ "etag": "\"v2\"" }`
+		})
+
+		It("emits just that version", func() {
+			Expect(response).To(Equal(check.Response{{ETag: `"v2"`}}))
+		})
+	})
+
+	Context("requesting an outdated version", func() {
+		BeforeEach(func() {
+			version = `{ "etag": "\"v1\"" }`
+		})
+
+		It("emits the current version", func() {
+			Expect(response).To(Equal(check.Response{{ETag: `"v2"`}}))
+		})
+	})
+
+	Context("with boundaries", func() {
+		BeforeEach(func() {
+			boundaries = `{}`
+			version = `{ "etag": "\"v2\"" }`
+		})
+
+		It("emits a new version once a window has started", func() {
+			Expect(response).To(Equal(check.Response{
+				{ETag: `"v2"`},
+				{ETag: `"v2"`, Boundary: "2023-10-11T16:00:00Z"},
+			}))
+		})
+	})
+
+	Context("failing to download", func() {
+		BeforeEach(func() {
+			server.Config.Handler = http.NotFoundHandler()
+		})
+
+		It("fails", func() {
+			Expect(err).To(MatchError(ContainSubstring("404 Not Found")))
+		})
+	})
+})
@@ -0,0 +1,34 @@
+package export
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/homeport/freeze-calendar-resource/freeze"
+	"github.com/spf13/cobra"
+)
+
+var Format string
+
+// IncludePaths maps URIs of included repositories to local directories, as for lint
+var IncludePaths map[string]string
+
+func RunE(cmd *cobra.Command, args []string) error {
+	if Format != "ics" {
+		return fmt.Errorf("unsupported format '%s'; the only supported format is ics", Format)
+	}
+
+	calendar, err := freeze.LoadFile(args[0], freeze.LocalFetcher(IncludePaths))
+
+	if err != nil {
+		return err
+	}
+
+	err = calendar.WriteICalendar(cmd.OutOrStdout(), time.Now())
+
+	if err != nil {
+		return fmt.Errorf("unable to export calendar: %w", err)
+	}
+
+	return nil
+}
@@ -1,8 +1,12 @@
 package freeze
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"iter"
+	"reflect"
+	"slices"
 	"strings"
 	"time"
 
@@ -11,39 +15,413 @@ import (
 )
 
 type Window struct {
-	Name  string    `yaml:"name" validate:"required"`
-	Start time.Time `yaml:"starts_at" validate:"required"`
-	End   time.Time `yaml:"ends_at" validate:"required,gtcsfield=Start"`
-	Scope []string  `yaml:"scope,omitempty"`
+	Name       string        `yaml:"name" validate:"required"`
+	Start      time.Time     `yaml:"starts_at" validate:"required"`
+	End        time.Time     `yaml:"ends_at,omitempty" validate:"required_without=Duration,excluded_with=Duration"`
+	Duration   time.Duration `yaml:"duration,omitempty" validate:"omitempty,gt=0"`
+	Recurrence *Recurrence   `yaml:"recurrence,omitempty"`
+	Except     []time.Time   `yaml:"except,omitempty"`
+	Scope      []string      `yaml:"scope,omitempty"`
+	TimeZone   string        `yaml:"timezone,omitempty" validate:"omitempty,timezone"`
+	Origin     string        `yaml:"-"` // the file the window was loaded from, if any
+
+	// the start of the occurrence of a recurring window (which has excepted it) that the window replaces, like an
+	// iCalendar event with a RECURRENCE-ID; zero if it replaces none
+	ReplacesOccurrence time.Time `yaml:"-"`
+
+	positions positions
+
+	// whether starts_at or ends_at were given without an offset and are to be resolved against the time zone
+	floatingStart  bool
+	floatingEnd    bool
+	floatingExcept []bool
+}
+
+type plainWindow Window
+
+// ZeroLengthError is the error of a window that does not end after it starts, e.g. once its time zone is resolved
+type ZeroLengthError struct {
+	Window     string
+	Start, End time.Time
+}
+
+func (e ZeroLengthError) Error() string {
+	return fmt.Sprintf("window '%s' ends at %s, which is not after its start at %s", e.Window, e.End, e.Start)
+}
+
+// UnmarshalYAML parses starts_at and ends_at with and without an offset. Timestamps without an offset are wall-clock
+// times that get resolved against the window's or the calendar's time zone by LoadCalendar.
+func (w *Window) UnmarshalYAML(node *yaml.Node) error {
+	rest, timestamps := splitMapping(node, "starts_at", "ends_at", "except")
+	start, end, except := timestamps["starts_at"], timestamps["ends_at"], timestamps["except"]
+
+	err := rest.Decode((*plainWindow)(w))
+
+	if err != nil {
+		return err
+	}
+
+	w.positions = positionsOf(node)
+	w.Start, w.floatingStart, err = parseTimestamp(start)
+
+	if err != nil {
+		return errorAt(start, fmt.Errorf("unable to parse starts_at: %w", err))
+	}
+
+	w.End, w.floatingEnd, err = parseTimestamp(end)
+
+	if err != nil {
+		return errorAt(end, fmt.Errorf("unable to parse ends_at: %w", err))
+	}
+
+	if except != nil {
+		if except.Kind != yaml.SequenceNode {
+			return errorAt(except, errors.New("except must be a list of timestamps"))
+		}
+
+		for _, node := range except.Content {
+			exception, floating, err := parseTimestamp(node)
+
+			if err != nil {
+				return errorAt(node, fmt.Errorf("unable to parse except: %w", err))
+			}
+
+			w.Except = append(w.Except, exception)
+			w.floatingExcept = append(w.floatingExcept, floating)
+		}
+	}
+
+	return nil
+}
+
+// Position returns where the first of keys that is defined (e.g. ends_at) was given in the calendar file, or where
+// the window itself was. It is zero for windows that were not loaded from YAML.
+func (w Window) Position(keys ...string) Position {
+	return w.positions.of(keys...)
 }
 
 func (w Window) String() (result string) {
 	result = fmt.Sprintf("%s from %s to %s", w.Name, w.Start, w.End)
 
+	if w.Recurrence != nil {
+		result += fmt.Sprintf("; recurring %s", w.Recurrence)
+	}
+
 	if len(w.Scope) > 0 {
 		result += fmt.Sprintf("; scope: %s", strings.Join(w.Scope, ", "))
 	}
 
+	if w.Origin != "" {
+		result += fmt.Sprintf("; defined in %s", w.Origin)
+	}
+
+	return
+}
+
+// Occurrences yields the concrete windows described by w in chronological order. A window without recurrence has exactly one occurrence, itself.
+//
+// Each occurrence keeps the wall-clock start and end times of the first one, so that a window from Friday 15:00 to Monday 06:00 still ends on Monday 06:00 after a DST change.
+func (w Window) Occurrences() iter.Seq[Window] {
+	return func(yield func(Window) bool) {
+		if w.Recurrence == nil {
+			yield(w)
+			return
+		}
+
+		startYear, startMonth, startDay := w.Start.Date()
+		endYear, endMonth, endDay := w.End.In(w.Start.Location()).Date()
+		days := int(time.Date(endYear, endMonth, endDay, 0, 0, 0, 0, time.UTC).Sub(time.Date(startYear, startMonth, startDay, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+		endHour, endMinute, endSecond := w.End.In(w.Start.Location()).Clock()
+
+		for start := range w.Recurrence.Starts(w.Start) {
+			if slices.ContainsFunc(w.Except, start.Equal) {
+				continue
+			}
+
+			year, month, day := start.Date()
+
+			occurrence := w
+			occurrence.Recurrence = nil
+			occurrence.Start = start
+			occurrence.End = time.Date(year, month, day+days, endHour, endMinute, endSecond, w.End.Nanosecond(), start.Location())
+
+			if !yield(occurrence) {
+				return
+			}
+		}
+	}
+}
+
+// ActiveAt returns the occurrence of w that includes t, if any.
+func (w Window) ActiveAt(t time.Time) (Window, bool) {
+	for occurrence := range w.Occurrences() {
+		if occurrence.Start.After(t) {
+			break
+		}
+
+		if !occurrence.End.Before(t) {
+			return occurrence, true
+		}
+	}
+
+	return Window{}, false
+}
+
+// Upcoming returns up to n occurrences of w that have not ended at t.
+func (w Window) Upcoming(t time.Time, n int) []Window {
+	var result []Window
+
+	for occurrence := range w.Occurrences() {
+		if len(result) >= n {
+			break
+		}
+
+		if occurrence.End.After(t) {
+			result = append(result, occurrence)
+		}
+	}
+
+	return result
+}
+
+// LastBoundary returns the latest time at or before t at which one of the windows overlapping scope became active or
+// inactive. As in get, a window counts as active while t plus runway lies within it.
+func (c Calendar) LastBoundary(t time.Time, scope []string, runway time.Duration) (last time.Time, found bool) {
+	for _, w := range c.Windows {
+		if !ScopesOverlap(w.Scope, scope) {
+			continue
+		}
+
+		for occurrence := range w.Occurrences() {
+			activation := occurrence.Start.Add(-runway)
+
+			if activation.After(t) {
+				break
+			}
+
+			for _, boundary := range []time.Time{activation, occurrence.End.Add(-runway)} {
+				if !boundary.After(t) && (!found || boundary.After(last)) {
+					last, found = boundary, true
+				}
+			}
+		}
+	}
+
 	return
 }
 
 type Calendar struct {
-	Windows []Window `yaml:"freeze_calendar" validate:"omitempty,dive"`
+	TimeZone  string     `yaml:"timezone,omitempty" validate:"omitempty,timezone"`
+	Windows   []Window   `yaml:"freeze_calendar" validate:"omitempty,dive"`
+	Overrides []Override `yaml:"overrides,omitempty" validate:"omitempty,dive"`
+	Includes  []Include  `yaml:"include,omitempty" validate:"omitempty,dive"`
+}
+
+// Merge combines the windows, overrides and includes of the calendars into one. Each calendar's time zone has already
+// been applied while loading it, so the merged calendar has no time zone of its own.
+func Merge(calendars ...*Calendar) *Calendar {
+	merged := &Calendar{}
+
+	for _, c := range calendars {
+		merged.Windows = append(merged.Windows, c.Windows...)
+		merged.Overrides = append(merged.Overrides, c.Overrides...)
+		merged.Includes = append(merged.Includes, c.Includes...)
+	}
+
+	return merged
 }
 
 func LoadCalendar(reader io.Reader) (*Calendar, error) {
+	var document yaml.Node
+	err := yaml.NewDecoder(reader).Decode(&document)
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to build decoder: %w", yamlErrors(err))
+	}
+
+	if unknown := unknownKeys(&document, reflect.TypeOf(Calendar{}), ""); len(unknown) > 0 {
+		return nil, fmt.Errorf("unable to build decoder: %w", errors.Join(unknown...))
+	}
+
 	var calendar Calendar
-	err := yaml.NewDecoder(reader).Decode(&calendar)
+	err = document.Decode(&calendar)
 
 	if err != nil {
-		return nil, fmt.Errorf("unable to build decoder: %w", err)
+		return nil, fmt.Errorf("unable to build decoder: %w", yamlErrors(err))
 	}
 
-	err = validator.New(validator.WithRequiredStructEnabled()).Struct(calendar)
+	err = calendar.complete(&document)
 
 	if err != nil {
-		return nil, fmt.Errorf("unable to build validator: %w", err)
+		return nil, err
 	}
 
 	return &calendar, nil
 }
+
+// complete validates the calendar, resolves its time zones and computes the end of windows that were given a duration.
+// Errors are located in document, the calendar's YAML source, if given.
+func (c *Calendar) complete(document *yaml.Node) error {
+	err := validator.New(validator.WithRequiredStructEnabled()).Struct(c)
+
+	if err != nil {
+		return fmt.Errorf("unable to build validator: %w", validationErrors(err, document))
+	}
+
+	var invalid []error
+
+	for _, w := range c.Windows {
+		if err := ValidateScope(w.Scope); err != nil {
+			invalid = append(invalid, w.positions.error(fmt.Errorf("invalid scope of window '%s': %w", w.Name, err), "scope"))
+		}
+	}
+
+	for _, o := range c.Overrides {
+		if err := ValidateScope(o.Scope); err != nil {
+			invalid = append(invalid, o.positions.error(fmt.Errorf("invalid scope of override approved by %s: %w", o.Approver, err), "scope"))
+		}
+	}
+
+	if len(invalid) > 0 {
+		return errors.Join(invalid...)
+	}
+
+	err = c.resolveTimeZones(document)
+
+	if err != nil {
+		return fmt.Errorf("unable to resolve time zones: %w", err)
+	}
+
+	for i, w := range c.Windows {
+		if w.End.IsZero() {
+			c.Windows[i].End = w.Start.Add(w.Duration)
+		}
+
+		// only comparable once both are resolved, as either may be a wall-clock time
+		if !c.Windows[i].End.After(w.Start) {
+			invalid = append(invalid, w.positions.error(ZeroLengthError{Window: w.Name, Start: w.Start, End: c.Windows[i].End}, "ends_at", "duration"))
+		}
+	}
+
+	return errors.Join(invalid...)
+}
+
+// resolveTimeZones interprets wall-clock timestamps in the time zone of their window, falling back to the calendar's
+// time zone and then UTC. Timestamps with an offset keep their instant, but are presented in the configured time zone.
+func (c *Calendar) resolveTimeZones(document *yaml.Node) error {
+	calendarLocation, err := time.LoadLocation(c.TimeZone)
+
+	if err != nil {
+		if document != nil {
+			return errorAt(lookup(document, reflect.TypeOf(Calendar{}), "Calendar.TimeZone"), err)
+		}
+
+		return err
+	}
+
+	for i := range c.Windows {
+		w := &c.Windows[i]
+		location := calendarLocation
+
+		if w.TimeZone != "" {
+			location, err = time.LoadLocation(w.TimeZone)
+
+			if err != nil {
+				return w.positions.error(err, "timezone")
+			}
+		}
+
+		configured := w.TimeZone != "" || c.TimeZone != ""
+		w.Start = resolve(w.Start, w.floatingStart, configured, location)
+		w.End = resolve(w.End, w.floatingEnd, configured, location)
+
+		if w.Recurrence != nil {
+			w.Recurrence.Until = resolve(w.Recurrence.Until, w.Recurrence.floatingUntil, configured, location)
+		}
+
+		for j := range w.Except {
+			w.Except[j] = resolve(w.Except[j], j < len(w.floatingExcept) && w.floatingExcept[j], configured, location)
+		}
+	}
+
+	for i := range c.Overrides {
+		o := &c.Overrides[i]
+		o.ExpiresAt = resolve(o.ExpiresAt, o.floatingExpiry, c.TimeZone != "", calendarLocation)
+	}
+
+	return nil
+}
+
+func resolve(t time.Time, floating, configured bool, location *time.Location) time.Time {
+	switch {
+	case t.IsZero():
+		return t
+	case floating:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), location)
+	case configured:
+		return t.In(location)
+	default:
+		return t
+	}
+}
+
+var (
+	timestampLayouts = []string{
+		"2006-1-2T15:4:5Z07:00",
+		"2006-1-2t15:4:5Z07:00",
+		"2006-1-2 15:4:5Z07:00",
+	}
+
+	wallClockLayouts = []string{
+		"2006-1-2T15:4:5",
+		"2006-1-2t15:4:5",
+		"2006-1-2 15:4:5",
+		"2006-1-2T15:4",
+		"2006-1-2 15:4",
+		"2006-1-2",
+	}
+)
+
+// splitMapping separates the values of the given keys from a mapping node, so that the rest can be decoded as usual
+func splitMapping(node *yaml.Node, keys ...string) (*yaml.Node, map[string]*yaml.Node) {
+	rest := *node
+	rest.Content = nil
+	values := map[string]*yaml.Node{}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if slices.Contains(keys, node.Content[i].Value) {
+			values[node.Content[i].Value] = node.Content[i+1]
+		} else {
+			rest.Content = append(rest.Content, node.Content[i], node.Content[i+1])
+		}
+	}
+
+	return &rest, values
+}
+
+// parseTimestamp parses a YAML timestamp. The result is floating if the timestamp has no offset; it is parsed as UTC then.
+func parseTimestamp(node *yaml.Node) (time.Time, bool, error) {
+	if node == nil || node.Tag == "!!null" {
+		return time.Time{}, false, nil
+	}
+
+	if node.Kind != yaml.ScalarNode {
+		return time.Time{}, false, errors.New("expected a timestamp")
+	}
+
+	value := strings.TrimSpace(node.Value)
+
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, false, nil
+		}
+	}
+
+	for _, layout := range wallClockLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true, nil
+		}
+	}
+
+	return time.Time{}, false, fmt.Errorf("cannot parse '%s' as timestamp", value)
+}
@@ -1,6 +1,7 @@
 package freeze_test
 
 import (
+	"errors"
 	"strings"
 	"time"
 
@@ -120,7 +121,13 @@ freeze_calendar:
 		})
 
 		It("has the expected error message", func() {
-			Expect(err).To(MatchError(ContainSubstring("validation for 'End' failed")))
+			Expect(err).To(MatchError("line 5, column 14: window 'Wrong order' ends at 2022-12-01 06:00:00 +0000 UTC, which is not after its start at 2022-12-27 06:00:00 +0000 UTC"))
+		})
+
+		It("locates the error", func() {
+			var positioned freeze.PositionError
+			Expect(errors.As(err, &positioned)).To(BeTrue())
+			Expect(positioned.Position).To(Equal(freeze.Position{Line: 5, Column: 14}))
 		})
 	})
 
@@ -139,3 +146,276 @@ freeze_calendar:
 		})
 	})
 })
+
+var _ = Describe("Time zones", func() {
+	var (
+		err      error
+		calendar *freeze.Calendar
+		content  string
+		berlin   *time.Location
+	)
+
+	BeforeEach(func() {
+		berlin, err = time.LoadLocation("Europe/Berlin")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	JustBeforeEach(func() {
+		calendar, err = freeze.LoadCalendar(strings.NewReader(content))
+	})
+
+	Context("wall-clock timestamps without any time zone", func() {
+		BeforeEach(func() {
+			content = `
+freeze_calendar:
+  - name: Christmas Eve
+    starts_at: 2023-12-24T18:00:00
+    ends_at: 2023-12-25 06:00
+`
+		})
+
+		It("works", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("interprets them as UTC", func() {
+			Expect(calendar.Windows[0].Start).To(Equal(time.Date(2023, time.December, 24, 18, 0, 0, 0, time.UTC)))
+			Expect(calendar.Windows[0].End).To(Equal(time.Date(2023, time.December, 25, 6, 0, 0, 0, time.UTC)))
+		})
+	})
+
+	Context("calendar-wide time zone", func() {
+		BeforeEach(func() {
+			content = `
+timezone: Europe/Berlin
+freeze_calendar:
+  - name: Christmas Eve
+    starts_at: 2023-12-24T18:00:00
+    ends_at: 2023-12-25T06:00:00Z
+`
+		})
+
+		It("works", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("resolves wall-clock timestamps against it", func() {
+			Expect(calendar.Windows[0].Start.Equal(time.Date(2023, time.December, 24, 17, 0, 0, 0, time.UTC))).To(BeTrue())
+		})
+
+		It("keeps the instant of timestamps with an offset", func() {
+			Expect(calendar.Windows[0].End.Equal(time.Date(2023, time.December, 25, 6, 0, 0, 0, time.UTC))).To(BeTrue())
+		})
+
+		It("presents all timestamps in the time zone", func() {
+			Expect(calendar.Windows[0].String()).To(Equal("Christmas Eve from 2023-12-24 18:00:00 +0100 CET to 2023-12-25 07:00:00 +0100 CET"))
+		})
+	})
+
+	Context("per-window time zone", func() {
+		BeforeEach(func() {
+			content = `
+timezone: America/New_York
+freeze_calendar:
+  - name: Christmas Eve
+    timezone: Europe/Berlin
+    starts_at: 2023-12-24T18:00:00
+    ends_at: 2023-12-27T06:00:00
+  - name: Thanksgiving
+    starts_at: 2023-11-23T00:00:00
+    ends_at: 2023-11-24T00:00:00
+`
+		})
+
+		It("overrides the calendar-wide one", func() {
+			Expect(calendar.Windows[0].Start.Location()).To(Equal(berlin))
+			Expect(calendar.Windows[0].Start.Equal(time.Date(2023, time.December, 24, 17, 0, 0, 0, time.UTC))).To(BeTrue())
+		})
+
+		It("falls back to the calendar-wide one", func() {
+			Expect(calendar.Windows[1].Start.Equal(time.Date(2023, time.November, 23, 5, 0, 0, 0, time.UTC))).To(BeTrue())
+		})
+	})
+
+	Context("recurring window across a DST change", func() {
+		BeforeEach(func() {
+			content = `
+timezone: Europe/Berlin
+freeze_calendar:
+  - name: Weekend
+    starts_at: 2023-10-20T15:00:00
+    ends_at: 2023-10-23T06:00:00
+    recurrence: FREQ=WEEKLY;BYDAY=FR;UNTIL=20231103T150000
+`
+		})
+
+		It("follows the local wall clock", func() {
+			occurrence, active := calendar.Windows[0].ActiveAt(time.Date(2023, time.October, 30, 4, 30, 0, 0, time.UTC)) // 05:30 CET
+			Expect(active).To(BeTrue())
+			Expect(occurrence.End.Equal(time.Date(2023, time.October, 30, 5, 0, 0, 0, time.UTC))).To(BeTrue())
+		})
+
+		It("resolves UNTIL in the time zone", func() {
+			Expect(calendar.Windows[0].Upcoming(time.Date(2023, time.October, 1, 0, 0, 0, 0, time.UTC), 5)).To(HaveLen(3))
+		})
+	})
+
+	Context("a wall-clock start and an end with offset", func() {
+		Context("that are in order once the start is resolved", func() {
+			BeforeEach(func() {
+				content = `
+timezone: Asia/Tokyo
+freeze_calendar:
+  - name: Black Friday
+    starts_at: 2023-11-24T18:00:00
+    ends_at: 2023-11-24T12:00:00Z
+`
+			})
+
+			It("is acceptable", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(calendar.Windows[0].Start.UTC()).To(Equal(time.Date(2023, time.November, 24, 9, 0, 0, 0, time.UTC)))
+			})
+		})
+
+		Context("that are not in order once the start is resolved", func() {
+			BeforeEach(func() {
+				content = `
+timezone: America/New_York
+freeze_calendar:
+  - name: Black Friday
+    starts_at: 2023-11-24T10:00:00
+    ends_at: 2023-11-24T12:00:00Z
+`
+			})
+
+			It("fails", func() {
+				Expect(err).To(MatchError(ContainSubstring("line 6, column 14: window 'Black Friday' ends at")))
+			})
+		})
+	})
+
+	Context("unknown time zone", func() {
+		BeforeEach(func() {
+			content = `
+timezone: Europe/Atlantis
+freeze_calendar: []
+`
+		})
+
+		It("fails", func() {
+			Expect(err).To(MatchError(ContainSubstring("validation for 'TimeZone' failed")))
+		})
+	})
+
+	Context("unparseable timestamp", func() {
+		BeforeEach(func() {
+			content = `
+freeze_calendar:
+  - name: Garbage
+    starts_at: tomorrow
+    ends_at: 2023-12-25T06:00:00Z
+`
+		})
+
+		It("fails", func() {
+			Expect(err).To(MatchError(ContainSubstring("cannot parse 'tomorrow' as timestamp")))
+		})
+
+		It("locates the error", func() {
+			Expect(err).To(MatchError(ContainSubstring("line 4, column 16: unable to parse starts_at")))
+		})
+	})
+
+	Context("unknown keys", func() {
+		BeforeEach(func() {
+			content = `
+timzone: Europe/Berlin
+freeze_calendar:
+  - name: Holiday Season
+    starts_at: 2023-12-24T06:00:00Z
+    end_at: 2023-12-27T06:00:00Z
+    scopes: [eu-de]
+`
+		})
+
+		It("fails with a suggestion for each", func() {
+			Expect(err).To(MatchError(ContainSubstring("line 2, column 1: unknown key 'timzone'; did you mean 'timezone'?")))
+			Expect(err).To(MatchError(ContainSubstring("line 6, column 5: unknown key 'end_at' in freeze_calendar[0]; did you mean 'ends_at'?")))
+			Expect(err).To(MatchError(ContainSubstring("line 7, column 5: unknown key 'scopes' in freeze_calendar[0]; did you mean 'scope'?")))
+		})
+	})
+
+	Context("type mismatch", func() {
+		BeforeEach(func() {
+			content = `
+freeze_calendar:
+  - name: Garbage
+    starts_at: 2023-12-24T06:00:00Z
+    duration: forever
+`
+		})
+
+		It("locates the error", func() {
+			var positioned freeze.PositionError
+			Expect(errors.As(err, &positioned)).To(BeTrue())
+			Expect(positioned.Line).To(Equal(5))
+		})
+	})
+
+	Context("window", func() {
+		BeforeEach(func() {
+			content = `
+freeze_calendar:
+  - name: Holiday Season
+    starts_at: 2023-12-24T06:00:00Z
+    ends_at: 2023-12-27T06:00:00Z
+`
+		})
+
+		It("knows where it was defined", func() {
+			Expect(calendar.Windows[0].Position()).To(Equal(freeze.Position{Line: 3, Column: 5}))
+			Expect(calendar.Windows[0].Position("ends_at")).To(Equal(freeze.Position{Line: 5, Column: 14}))
+			Expect(calendar.Windows[0].Position("duration")).To(Equal(freeze.Position{Line: 3, Column: 5}))
+		})
+	})
+})
+
+var _ = Describe("Boundaries", func() {
+	var calendar *freeze.Calendar
+
+	BeforeEach(func() {
+		var err error
+		calendar, err = freeze.LoadCalendar(strings.NewReader(`
+freeze_calendar:
+  - name: Weekend
+    starts_at: 2023-09-01T15:00:00Z
+    duration: 63h
+    recurrence: FREQ=WEEKLY;BYDAY=FR
+  - name: Oktoberfest
+    starts_at: 2023-10-11T16:00:00Z
+    ends_at: 2023-10-12T00:00:00Z
+    scope: [eu-de]
+`))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	DescribeTable("last boundary",
+		func(t time.Time, scope []string, runway time.Duration, expected time.Time) {
+			boundary, found := calendar.LastBoundary(t, scope, runway)
+
+			if expected.IsZero() {
+				Expect(found).To(BeFalse())
+			} else {
+				Expect(found).To(BeTrue())
+				Expect(boundary).To(BeTemporally("==", expected))
+			}
+		},
+		Entry("before any window", time.Date(2023, time.August, 1, 0, 0, 0, 0, time.UTC), nil, time.Duration(0), time.Time{}),
+		Entry("within a window", time.Date(2023, time.September, 2, 0, 0, 0, 0, time.UTC), nil, time.Duration(0), time.Date(2023, time.September, 1, 15, 0, 0, 0, time.UTC)),
+		Entry("after a window", time.Date(2023, time.September, 5, 0, 0, 0, 0, time.UTC), nil, time.Duration(0), time.Date(2023, time.September, 4, 6, 0, 0, 0, time.UTC)),
+		Entry("within scope", time.Date(2023, time.October, 11, 17, 0, 0, 0, time.UTC), []string{"eu-de"}, time.Duration(0), time.Date(2023, time.October, 11, 16, 0, 0, 0, time.UTC)),
+		Entry("out of scope", time.Date(2023, time.October, 11, 17, 0, 0, 0, time.UTC), []string{"us-east"}, time.Duration(0), time.Date(2023, time.October, 9, 6, 0, 0, 0, time.UTC)),
+		Entry("with runway", time.Date(2023, time.October, 11, 15, 30, 0, 0, time.UTC), []string{"eu-de"}, time.Hour, time.Date(2023, time.October, 11, 15, 0, 0, 0, time.UTC)),
+	)
+})
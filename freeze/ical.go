@@ -0,0 +1,395 @@
+package freeze
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Load decodes a calendar with the loader matching the extension of path; iCalendar files for .ics, YAML for anything else.
+// The windows and overrides are tagged with path as their origin.
+func Load(path string, reader io.Reader) (calendar *Calendar, err error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ics", ".ical", ".icalendar":
+		calendar, err = LoadICalendar(reader)
+	default:
+		calendar, err = LoadCalendar(reader)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range calendar.Windows {
+		calendar.Windows[i].Origin = path
+	}
+
+	for i := range calendar.Overrides {
+		calendar.Overrides[i].Origin = path
+	}
+
+	return calendar, nil
+}
+
+// LoadICalendar reads the VEVENTs of an iCalendar (RFC 5545) file as freeze windows:
+//
+//   - SUMMARY becomes the name,
+//   - DTSTART and DTEND (or DURATION) become start and end,
+//   - CATEGORIES becomes the scope,
+//   - RRULE and EXDATE become recurrence and exceptions, and
+//   - events with a RECURRENCE-ID replace the respective occurrence of their recurring event.
+//
+// Cancelled events are ignored, and so are events with a DATE-TIME start but neither DTEND nor DURATION, as they end
+// when they start; with a DATE start, they last the day. The resulting calendar is validated the same way as one loaded
+// by LoadCalendar.
+func LoadICalendar(reader io.Reader) (*Calendar, error) {
+	lines, err := unfold(reader)
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to read iCalendar: %w", err)
+	}
+
+	var calendar Calendar
+	var event []contentLine
+	var inEvent bool
+	var depth int // of components nested within a VEVENT, e.g. VALARM
+
+	type recurringEvent struct {
+		index int
+		uid   string
+	}
+
+	type replacedOccurrence struct {
+		recurrenceID contentLine
+		uid          string
+		window       int // the index of the replacing window, or -1 if the occurrence is cancelled
+	}
+
+	type replacement struct {
+		window, master, except int // indexes of the replacing window, its recurring one and the exception there
+	}
+
+	var masters []recurringEvent
+	var replaced []replacedOccurrence
+	var replacements []replacement
+
+	for i, raw := range lines {
+		line, err := parseContentLine(raw)
+
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		switch {
+		case line.name == "BEGIN" && strings.EqualFold(line.value, "VEVENT"):
+			inEvent = true
+			event = nil
+		case line.name == "BEGIN" && inEvent:
+			depth++
+		case line.name == "END" && inEvent && depth > 0:
+			depth--
+		case line.name == "END" && strings.EqualFold(line.value, "VEVENT"):
+			inEvent = false
+			window, uid, recurrenceID, skip, err := eventToWindow(event)
+
+			if err != nil {
+				return nil, fmt.Errorf("unable to convert event ending in line %d: %w", i+1, err)
+			}
+
+			// a cancelled occurrence is replaced by nothing, but still excepted from its recurring event
+			if recurrenceID != nil {
+				occurrence := replacedOccurrence{recurrenceID: *recurrenceID, uid: uid, window: len(calendar.Windows)}
+
+				if skip {
+					occurrence.window = -1
+				}
+
+				replaced = append(replaced, occurrence)
+			}
+
+			if skip {
+				continue
+			}
+
+			if window.Recurrence != nil {
+				masters = append(masters, recurringEvent{index: len(calendar.Windows), uid: uid})
+			}
+
+			calendar.Windows = append(calendar.Windows, window)
+		case inEvent && depth == 0:
+			event = append(event, line)
+		case !inEvent && line.name == "X-WR-TIMEZONE":
+			calendar.TimeZone = ianaTimeZone(line.value)
+		}
+	}
+
+	for _, occurrence := range replaced {
+		for _, master := range masters {
+			if master.uid != occurrence.uid {
+				continue
+			}
+
+			t, floating, err := occurrence.recurrenceID.time()
+
+			if err != nil {
+				return nil, fmt.Errorf("invalid RECURRENCE-ID: %w", err)
+			}
+
+			if occurrence.window >= 0 {
+				replacements = append(replacements, replacement{
+					window: occurrence.window,
+					master: master.index,
+					except: len(calendar.Windows[master.index].Except),
+				})
+			}
+
+			calendar.Windows[master.index].Except = append(calendar.Windows[master.index].Except, t)
+			calendar.Windows[master.index].floatingExcept = append(calendar.Windows[master.index].floatingExcept, floating)
+		}
+	}
+
+	err = calendar.complete(nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the replaced occurrence is known once the exception is resolved against the time zone of the recurring window
+	for _, r := range replacements {
+		calendar.Windows[r.window].ReplacesOccurrence = calendar.Windows[r.master].Except[r.except]
+	}
+
+	return &calendar, nil
+}
+
+type contentLine struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// time parses a DATE or DATE-TIME value. The result is floating if it has neither a trailing Z nor a TZID.
+func (l contentLine) time() (time.Time, bool, error) {
+	location := time.UTC
+	floating := !strings.HasSuffix(l.value, "Z")
+
+	if tzid, found := l.params["TZID"]; found {
+		var err error
+		location, err = time.LoadLocation(ianaTimeZone(tzid))
+
+		if err != nil {
+			return time.Time{}, false, err
+		}
+
+		floating = false
+	}
+
+	t, err := parseICalendarTime(l.value, location)
+
+	return t, floating, err
+}
+
+func eventToWindow(lines []contentLine) (window Window, uid string, recurrenceID *contentLine, skip bool, err error) {
+	var start, end, duration *contentLine
+
+	for _, line := range lines {
+		switch line.name {
+		case "UID":
+			uid = line.value
+		case "SUMMARY":
+			window.Name = unescape(line.value)
+		case "DTSTART":
+			start = &line
+		case "DTEND":
+			end = &line
+		case "DURATION":
+			duration = &line
+		case "CATEGORIES":
+			window.Scope = append(window.Scope, splitText(line.value)...)
+		case "RRULE":
+			window.Recurrence, err = ParseRecurrence(line.value)
+
+			if err != nil {
+				return
+			}
+		case "EXDATE":
+			for value := range strings.SplitSeq(line.value, ",") {
+				exception := line
+				exception.value = value
+
+				var t time.Time
+				var floating bool
+				t, floating, err = exception.time()
+
+				if err != nil {
+					return
+				}
+
+				window.Except = append(window.Except, t)
+				window.floatingExcept = append(window.floatingExcept, floating)
+			}
+		case "RECURRENCE-ID":
+			recurrenceID = &line
+		case "STATUS":
+			skip = strings.EqualFold(line.value, "CANCELLED")
+		}
+	}
+
+	if start == nil {
+		err = fmt.Errorf("event '%s' has no DTSTART", window.Name)
+		return
+	}
+
+	window.Start, window.floatingStart, err = start.time()
+
+	if err != nil {
+		return
+	}
+
+	if tzid, found := start.params["TZID"]; found {
+		window.TimeZone = ianaTimeZone(tzid)
+	}
+
+	switch {
+	case end != nil:
+		window.End, window.floatingEnd, err = end.time()
+	case duration != nil:
+		window.End, err = addICalendarDuration(window.Start, duration.value)
+		window.floatingEnd = window.floatingStart
+	case start.params["VALUE"] == "DATE":
+		window.End = window.Start.AddDate(0, 0, 1)
+		window.floatingEnd = window.floatingStart
+	default:
+		skip = true // ends when it starts (RFC 5545, section 3.6.1), so it cannot freeze anything
+	}
+
+	return
+}
+
+// addICalendarDuration adds a DURATION value like P1D or PT1H30M to t. Days and weeks are added as calendar days.
+func addICalendarDuration(t time.Time, value string) (time.Time, error) {
+	rest, found := strings.CutPrefix(strings.TrimPrefix(value, "+"), "P")
+
+	if !found {
+		return time.Time{}, fmt.Errorf("invalid duration '%s'", value)
+	}
+
+	inTime := false
+	number := ""
+
+	for _, c := range rest {
+		switch {
+		case c == 'T':
+			inTime = true
+		case c >= '0' && c <= '9':
+			number += string(c)
+		default:
+			n, err := strconv.Atoi(number)
+
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid duration '%s'", value)
+			}
+
+			number = ""
+
+			switch {
+			case c == 'W' && !inTime:
+				t = t.AddDate(0, 0, 7*n)
+			case c == 'D' && !inTime:
+				t = t.AddDate(0, 0, n)
+			case c == 'H' && inTime:
+				t = t.Add(time.Duration(n) * time.Hour)
+			case c == 'M' && inTime:
+				t = t.Add(time.Duration(n) * time.Minute)
+			case c == 'S' && inTime:
+				t = t.Add(time.Duration(n) * time.Second)
+			default:
+				return time.Time{}, fmt.Errorf("invalid duration '%s'", value)
+			}
+		}
+	}
+
+	return t, nil
+}
+
+// unfold reads content lines, joining continuation lines that begin with a space or tab
+func unfold(reader io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, scanner.Err()
+}
+
+// parseContentLine splits a line like DTSTART;TZID=Europe/Berlin:20231224T180000 into name, parameters and value
+func parseContentLine(raw string) (contentLine, error) {
+	line := contentLine{params: map[string]string{}}
+	quoted := false
+	nameEnd := -1
+
+	for i, c := range raw {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == ';' && nameEnd < 0:
+			nameEnd = i
+		case c == ':':
+			if nameEnd < 0 {
+				nameEnd = i
+			}
+
+			line.name = strings.ToUpper(raw[:nameEnd])
+			line.value = raw[i+1:]
+
+			for param := range strings.SplitSeq(raw[nameEnd:i], ";") {
+				if key, value, found := strings.Cut(param, "="); found {
+					line.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+				}
+			}
+
+			return line, nil
+		}
+	}
+
+	return line, fmt.Errorf("'%s' is not a content line", raw)
+}
+
+func unescape(text string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(text)
+}
+
+// splitText splits a list of TEXT values at unescaped commas
+func splitText(value string) []string {
+	var result []string
+	start := 0
+
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',':
+			result = append(result, unescape(value[start:i]))
+			start = i + 1
+		}
+	}
+
+	return append(result, unescape(value[start:]))
+}
@@ -0,0 +1,274 @@
+package freeze
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	iCalendarProductID = "-//homeport//freeze-calendar-resource//EN"
+	iCalendarUTC       = "20060102T150405Z"
+	iCalendarLocal     = "20060102T150405"
+)
+
+// WriteICalendar renders the calendar as iCalendar (RFC 5545) with one VEVENT per window. The UID of each event is
+// derived from the window's name, start and scope, so that calendar apps subscribing to the export recognize it across
+// updates. A window that replaces an occurrence of a recurring one is written with the UID of that window and the
+// occurrence as RECURRENCE-ID. stamp is the creation time of the export (DTSTAMP).
+func (c Calendar) WriteICalendar(writer io.Writer, stamp time.Time) error {
+	w := bufio.NewWriter(writer)
+	ical := iCalendarWriter{w}
+
+	ical.line("BEGIN:VCALENDAR")
+	ical.line("VERSION:2.0")
+	ical.line("PRODID:" + iCalendarProductID)
+	ical.line("CALSCALE:GREGORIAN")
+
+	if c.TimeZone != "" {
+		ical.line("X-WR-TIMEZONE:" + c.TimeZone)
+	}
+
+	for _, location := range c.locations() {
+		first, last := c.years(location)
+		ical.timeZone(location, first, last)
+	}
+
+	series := c.series()
+
+	for i, window := range c.Windows {
+		ical.line("BEGIN:VEVENT")
+
+		if master, found := series[i]; found {
+			ical.line("UID:" + c.Windows[master].UID())
+			ical.line(iCalendarTime("RECURRENCE-ID", window.ReplacesOccurrence.In(c.Windows[master].Start.Location())))
+		} else {
+			ical.line("UID:" + window.UID())
+		}
+
+		ical.line("DTSTAMP:" + stamp.UTC().Format(iCalendarUTC))
+		ical.line("SUMMARY:" + escape(window.Name))
+		ical.line(iCalendarTime("DTSTART", window.Start))
+		ical.line(iCalendarTime("DTEND", window.End))
+
+		if window.Recurrence != nil {
+			ical.line("RRULE:" + window.Recurrence.String())
+		}
+
+		for _, exception := range window.Except {
+			// a replaced occurrence is overridden by its replacement rather than excluded
+			if !c.isReplaced(series, i, exception) {
+				ical.line(iCalendarTime("EXDATE", exception.In(window.Start.Location())))
+			}
+		}
+
+		if len(window.Scope) > 0 {
+			var categories []string
+
+			for _, scope := range window.Scope {
+				categories = append(categories, escape(scope))
+			}
+
+			ical.line("CATEGORIES:" + strings.Join(categories, ","))
+		}
+
+		ical.line("END:VEVENT")
+	}
+
+	ical.line("END:VCALENDAR")
+
+	return w.Flush()
+}
+
+// UID identifies the window by its name, start and scope
+func (w Window) UID() string {
+	id := w.Name + "\x00" + w.Start.UTC().Format(time.RFC3339) + "\x00" + strings.Join(w.Scope, ",")
+
+	return fmt.Sprintf("%x@freeze-calendar-resource", sha1.Sum([]byte(id)))
+}
+
+// series returns the index of the recurring window by the index of each window that replaces one of its occurrences.
+// Of several recurring windows that have excepted the occurrence, the one with the same name is preferred.
+func (c Calendar) series() map[int]int {
+	result := map[int]int{}
+
+	for i, w := range c.Windows {
+		if w.ReplacesOccurrence.IsZero() {
+			continue
+		}
+
+		for j, master := range c.Windows {
+			if master.Recurrence == nil || !slices.ContainsFunc(master.Except, w.ReplacesOccurrence.Equal) {
+				continue
+			}
+
+			if _, found := result[i]; !found || master.Name == w.Name {
+				result[i] = j
+			}
+		}
+	}
+
+	return result
+}
+
+// isReplaced tells whether the occurrence at the given start of the recurring window at index master is replaced by
+// another window
+func (c Calendar) isReplaced(series map[int]int, master int, start time.Time) bool {
+	for i, j := range series {
+		if j == master && c.Windows[i].ReplacesOccurrence.Equal(start) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// locations returns the named time zones of all windows, except UTC
+func (c Calendar) locations() []*time.Location {
+	var result []*time.Location
+
+	for _, w := range c.Windows {
+		location := w.Start.Location()
+
+		if hasTZID(location) && !slices.ContainsFunc(result, func(l *time.Location) bool { return l.String() == location.String() }) {
+			result = append(result, location)
+		}
+	}
+
+	return result
+}
+
+// years returns the first and last year for which the given location needs to be described. Unbounded recurrences are
+// covered for a decade beyond their last known date.
+func (c Calendar) years(location *time.Location) (int, int) {
+	first, last := 0, 0
+
+	for _, w := range c.Windows {
+		if w.Start.Location().String() != location.String() {
+			continue
+		}
+
+		end := w.End
+
+		if w.Recurrence != nil {
+			if w.Recurrence.Until.After(end) {
+				end = w.Recurrence.Until
+			}
+
+			if w.Recurrence.Until.IsZero() {
+				end = end.AddDate(10, 0, 0)
+			}
+		}
+
+		if first == 0 || w.Start.Year() < first {
+			first = w.Start.Year()
+		}
+
+		last = max(last, end.Year())
+	}
+
+	return first, last
+}
+
+func hasTZID(location *time.Location) bool {
+	name := location.String()
+	return name != "" && name != "UTC" && name != "Local"
+}
+
+func iCalendarTime(property string, t time.Time) string {
+	if hasTZID(t.Location()) {
+		return fmt.Sprintf("%s;TZID=%s:%s", property, t.Location(), t.Format(iCalendarLocal))
+	}
+
+	return fmt.Sprintf("%s:%s", property, t.UTC().Format(iCalendarUTC))
+}
+
+type iCalendarWriter struct {
+	*bufio.Writer
+}
+
+// line writes a content line, folded after 75 octets
+func (w iCalendarWriter) line(content string) {
+	for len(content) > 75 {
+		cut := 75
+
+		// do not split multi-byte characters
+		for cut > 0 && content[cut]&0xC0 == 0x80 {
+			cut--
+		}
+
+		w.WriteString(content[:cut] + "\r\n ")
+		content = content[cut:]
+	}
+
+	w.WriteString(content + "\r\n")
+}
+
+// timeZone writes a VTIMEZONE with one observance per offset change between the first and last year
+func (w iCalendarWriter) timeZone(location *time.Location, first, last int) {
+	w.line("BEGIN:VTIMEZONE")
+	w.line("TZID:" + location.String())
+
+	t := time.Date(first, time.January, 1, 0, 0, 0, 0, location)
+	until := time.Date(last+1, time.January, 1, 0, 0, 0, 0, location)
+	start, _ := t.ZoneBounds()
+	_, previousOffset := t.Zone()
+
+	if !start.IsZero() {
+		_, previousOffset = start.Add(-time.Second).Zone()
+	} else {
+		start = time.Date(1970, time.January, 1, 0, 0, 0, 0, location)
+	}
+
+	for {
+		name, offset := t.Zone()
+
+		component := "STANDARD"
+
+		if t.IsDST() {
+			component = "DAYLIGHT"
+		}
+
+		w.line("BEGIN:" + component)
+		w.line("DTSTART:" + start.In(time.FixedZone("", previousOffset)).Format(iCalendarLocal))
+		w.line("TZOFFSETFROM:" + formatOffset(previousOffset))
+		w.line("TZOFFSETTO:" + formatOffset(offset))
+		w.line("TZNAME:" + name)
+		w.line("END:" + component)
+
+		_, next := t.ZoneBounds()
+
+		if next.IsZero() || !next.Before(until) {
+			break
+		}
+
+		previousOffset = offset
+		start = next
+		t = next
+	}
+
+	w.line("END:VTIMEZONE")
+}
+
+func formatOffset(seconds int) string {
+	sign := "+"
+
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+
+	if seconds%60 != 0 {
+		return fmt.Sprintf("%s%02d%02d%02d", sign, seconds/3600, seconds/60%60, seconds%60)
+	}
+
+	return fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds/60%60)
+}
+
+func escape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(text)
+}
@@ -0,0 +1,460 @@
+package freeze_test
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/homeport/freeze-calendar-resource/freeze"
+)
+
+var _ = Describe("iCalendar", func() {
+	var (
+		err      error
+		calendar *freeze.Calendar
+		content  string
+	)
+
+	JustBeforeEach(func() {
+		calendar, err = freeze.Load("calendar.ics", strings.NewReader(content))
+	})
+
+	Context("example file", func() {
+		BeforeEach(func() {
+			example, err := os.ReadFile("../examples/freeze-calendar.ics")
+			Expect(err).ToNot(HaveOccurred())
+			content = string(example)
+		})
+
+		It("works", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("has all events as windows", func() {
+			Expect(calendar.Windows).To(HaveLen(2))
+		})
+
+		Context("first window", func() {
+			var window freeze.Window
+
+			JustBeforeEach(func() {
+				window = calendar.Windows[0]
+			})
+
+			It("has the SUMMARY as name", func() {
+				Expect(window.Name).To(Equal("Holiday Season"))
+			})
+
+			It("has the expected start", func() {
+				Expect(window.Start.Equal(time.Unix(1669874400, 0))).To(BeTrue())
+			})
+
+			It("has the expected end", func() {
+				Expect(window.End.Equal(time.Unix(1672120800, 0))).To(BeTrue())
+			})
+
+			It("has the CATEGORIES as scope", func() {
+				Expect(window.Scope).To(HaveExactElements("eu-de", "us-east", "ap-southeast"))
+			})
+		})
+
+		Context("recurring window", func() {
+			var window freeze.Window
+
+			JustBeforeEach(func() {
+				window = calendar.Windows[1]
+			})
+
+			It("has a recurrence", func() {
+				Expect(window.Recurrence).ToNot(BeNil())
+			})
+
+			It("resolves floating times against X-WR-TIMEZONE", func() {
+				Expect(window.Start.Equal(time.Date(2023, time.September, 1, 13, 0, 0, 0, time.UTC))).To(BeTrue())
+			})
+
+			It("is active on a regular weekend", func() {
+				_, active := window.ActiveAt(time.Date(2023, time.September, 30, 12, 0, 0, 0, time.UTC))
+				Expect(active).To(BeTrue())
+			})
+
+			It("skips the EXDATE", func() {
+				_, active := window.ActiveAt(time.Date(2023, time.October, 7, 12, 0, 0, 0, time.UTC))
+				Expect(active).To(BeFalse())
+			})
+		})
+	})
+
+	Context("events with TZID, DURATION, folded lines and escaped text", func() {
+		BeforeEach(func() {
+			content = strings.Join([]string{
+				"BEGIN:VCALENDAR",
+				"BEGIN:VEVENT",
+				"SUMMARY:Year-end closing\\, part 1",
+				"DTSTART;TZID=America/New_York:20231229T170000",
+				"DURATION:P3DT12H",
+				"CATEGORIES:us-east,us-",
+				" west",
+				"BEGIN:VALARM",
+				"TRIGGER:-PT15M",
+				"DESCRIPTION:Reminder",
+				"END:VALARM",
+				"END:VEVENT",
+				"BEGIN:VEVENT",
+				"SUMMARY:Cancelled",
+				"STATUS:CANCELLED",
+				"DTSTART:20231229T170000Z",
+				"DTEND:20231230T170000Z",
+				"END:VEVENT",
+				"END:VCALENDAR",
+			}, "\r\n")
+		})
+
+		It("works", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("ignores cancelled events", func() {
+			Expect(calendar.Windows).To(HaveLen(1))
+		})
+
+		It("unescapes the name", func() {
+			Expect(calendar.Windows[0].Name).To(Equal("Year-end closing, part 1"))
+		})
+
+		It("uses the TZID as time zone", func() {
+			Expect(calendar.Windows[0].TimeZone).To(Equal("America/New_York"))
+			Expect(calendar.Windows[0].Start.Equal(time.Date(2023, time.December, 29, 22, 0, 0, 0, time.UTC))).To(BeTrue())
+		})
+
+		It("computes the end from the duration", func() {
+			Expect(calendar.Windows[0].End.Equal(time.Date(2024, time.January, 2, 10, 0, 0, 0, time.UTC))).To(BeTrue())
+		})
+
+		It("unfolds continuation lines", func() {
+			Expect(calendar.Windows[0].Scope).To(HaveExactElements("us-east", "us-west"))
+		})
+	})
+
+	Context("Outlook export with Windows time zone names", func() {
+		BeforeEach(func() {
+			content = strings.Join([]string{
+				"BEGIN:VCALENDAR",
+				"PRODID:-//Microsoft Corporation//Outlook 16.0 MIMEDIR//EN",
+				"VERSION:2.0",
+				"METHOD:PUBLISH",
+				"X-WR-TIMEZONE:Pacific Standard Time",
+				"BEGIN:VTIMEZONE",
+				"TZID:W. Europe Standard Time",
+				"BEGIN:STANDARD",
+				"DTSTART:16011028T030000",
+				"RRULE:FREQ=YEARLY;BYDAY=-1SU;BYMONTH=10",
+				"TZOFFSETFROM:+0200",
+				"TZOFFSETTO:+0100",
+				"END:STANDARD",
+				"BEGIN:DAYLIGHT",
+				"DTSTART:16010325T020000",
+				"RRULE:FREQ=YEARLY;BYDAY=-1SU;BYMONTH=3",
+				"TZOFFSETFROM:+0100",
+				"TZOFFSETTO:+0200",
+				"END:DAYLIGHT",
+				"END:VTIMEZONE",
+				"BEGIN:VEVENT",
+				"CLASS:PUBLIC",
+				"CATEGORIES:eu-de",
+				"DTEND;TZID=\"W. Europe Standard Time\":20231227T060000",
+				"DTSTAMP:20231101T120000Z",
+				"DTSTART;TZID=\"W. Europe Standard Time\":20231224T180000",
+				"EXDATE;TZID=\"W. Europe Standard Time\":20241224T180000",
+				"RRULE:FREQ=YEARLY",
+				"SUMMARY;LANGUAGE=en-us:Christmas",
+				"UID:040000008200E00074C5B7101A82E00800000000",
+				"END:VEVENT",
+				"BEGIN:VEVENT",
+				"DTSTART:20231123T000000",
+				"DTEND:20231124T000000",
+				"SUMMARY:Thanksgiving",
+				"END:VEVENT",
+				"END:VCALENDAR",
+			}, "\r\n")
+		})
+
+		It("works", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("maps the TZID to its IANA time zone", func() {
+			Expect(calendar.Windows[0].TimeZone).To(Equal("Europe/Berlin"))
+			Expect(calendar.Windows[0].Start.Equal(time.Date(2023, time.December, 24, 17, 0, 0, 0, time.UTC))).To(BeTrue())
+		})
+
+		It("maps the TZID of the EXDATE", func() {
+			Expect(calendar.Windows[0].Except).To(HaveLen(1))
+			Expect(calendar.Windows[0].Except[0].Equal(time.Date(2024, time.December, 24, 17, 0, 0, 0, time.UTC))).To(BeTrue())
+		})
+
+		It("maps X-WR-TIMEZONE", func() {
+			Expect(calendar.TimeZone).To(Equal("America/Los_Angeles"))
+			Expect(calendar.Windows[1].Start.Equal(time.Date(2023, time.November, 23, 8, 0, 0, 0, time.UTC))).To(BeTrue())
+		})
+	})
+
+	Context("event with an unknown TZID", func() {
+		BeforeEach(func() {
+			content = "BEGIN:VCALENDAR\nBEGIN:VEVENT\nSUMMARY:Somewhere\nDTSTART;TZID=Atlantis Standard Time:20231229T170000\nDURATION:PT1H\nEND:VEVENT\nEND:VCALENDAR\n"
+		})
+
+		It("fails", func() {
+			Expect(err).To(MatchError(ContainSubstring("Atlantis Standard Time")))
+		})
+	})
+
+	Context("all-day event", func() {
+		BeforeEach(func() {
+			content = "BEGIN:VCALENDAR\nBEGIN:VEVENT\nSUMMARY:Inventory\nDTSTART;VALUE=DATE:20231231\nEND:VEVENT\nEND:VCALENDAR\n"
+		})
+
+		It("lasts one day", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calendar.Windows[0].End.Sub(calendar.Windows[0].Start)).To(Equal(24 * time.Hour))
+		})
+	})
+
+	Context("event with a start time, but neither end nor duration", func() {
+		BeforeEach(func() {
+			content = "BEGIN:VCALENDAR\nBEGIN:VEVENT\nSUMMARY:Reminder\nDTSTART:20231229T170000Z\nEND:VEVENT\nBEGIN:VEVENT\nSUMMARY:Inventory\nDTSTART;VALUE=DATE:20231231\nEND:VEVENT\nEND:VCALENDAR\n"
+		})
+
+		It("is ignored, as it ends when it starts", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calendar.Windows).To(HaveLen(1))
+			Expect(calendar.Windows[0].Name).To(Equal("Inventory"))
+		})
+	})
+
+	Context("modified occurrence of a recurring event", func() {
+		BeforeEach(func() {
+			content = strings.Join([]string{
+				"BEGIN:VCALENDAR",
+				"BEGIN:VEVENT",
+				"UID:standup",
+				"SUMMARY:Release train",
+				"DTSTART:20231002T080000Z",
+				"DTEND:20231002T100000Z",
+				"RRULE:FREQ=WEEKLY;COUNT=3",
+				"END:VEVENT",
+				"BEGIN:VEVENT",
+				"UID:standup",
+				"RECURRENCE-ID:20231009T080000Z",
+				"SUMMARY:Release train (moved)",
+				"DTSTART:20231010T080000Z",
+				"DTEND:20231010T100000Z",
+				"END:VEVENT",
+				"END:VCALENDAR",
+			}, "\n")
+		})
+
+		It("replaces the original occurrence", func() {
+			Expect(err).ToNot(HaveOccurred())
+			_, active := calendar.Windows[0].ActiveAt(time.Date(2023, time.October, 9, 9, 0, 0, 0, time.UTC))
+			Expect(active).To(BeFalse())
+			_, active = calendar.Windows[1].ActiveAt(time.Date(2023, time.October, 10, 9, 0, 0, 0, time.UTC))
+			Expect(active).To(BeTrue())
+		})
+	})
+
+	Context("cancelled occurrence of a recurring event", func() {
+		BeforeEach(func() {
+			content = strings.Join([]string{
+				"BEGIN:VCALENDAR",
+				"BEGIN:VEVENT",
+				"UID:weekend",
+				"SUMMARY:Weekend",
+				"DTSTART:20230930T000000Z",
+				"DTEND:20231002T000000Z",
+				"RRULE:FREQ=WEEKLY;COUNT=3",
+				"END:VEVENT",
+				"BEGIN:VEVENT",
+				"UID:weekend",
+				"RECURRENCE-ID:20231007T000000Z",
+				"SUMMARY:Weekend",
+				"STATUS:CANCELLED",
+				"DTSTART:20231007T000000Z",
+				"DTEND:20231009T000000Z",
+				"END:VEVENT",
+				"END:VCALENDAR",
+			}, "\n")
+		})
+
+		It("does not freeze", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calendar.Windows).To(HaveLen(1))
+			_, active := calendar.Windows[0].ActiveAt(time.Date(2023, time.October, 7, 12, 0, 0, 0, time.UTC))
+			Expect(active).To(BeFalse())
+			_, active = calendar.Windows[0].ActiveAt(time.Date(2023, time.October, 14, 12, 0, 0, 0, time.UTC))
+			Expect(active).To(BeTrue())
+		})
+	})
+
+	Context("event without SUMMARY", func() {
+		BeforeEach(func() {
+			content = "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20231229T170000Z\nDTEND:20231230T170000Z\nEND:VEVENT\nEND:VCALENDAR\n"
+		})
+
+		It("fails the same validation as YAML calendars", func() {
+			Expect(err).To(MatchError(ContainSubstring("validation for 'Name' failed")))
+		})
+	})
+})
+
+var _ = Describe("iCalendar export", func() {
+	var (
+		err      error
+		original *freeze.Calendar
+		exported strings.Builder
+	)
+
+	BeforeEach(func() {
+		exported = strings.Builder{}
+		original, err = freeze.LoadCalendar(strings.NewReader(`
+timezone: Europe/Berlin
+freeze_calendar:
+  - name: Holiday Season; with a twist, really
+    starts_at: 2022-12-01T06:00:00Z
+    ends_at: 2022-12-27T06:00:00Z
+    scope:
+      - eu-de
+      - us-east
+  - name: Weekend
+    starts_at: 2023-09-01T15:00:00
+    duration: 63h
+    recurrence: FREQ=WEEKLY;BYDAY=FR
+    except:
+      - 2023-10-06T15:00:00
+`))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	JustBeforeEach(func() {
+		err = original.WriteICalendar(&exported, time.Date(2023, time.September, 1, 0, 0, 0, 0, time.UTC))
+	})
+
+	It("works", func() {
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("is a VCALENDAR", func() {
+		Expect(exported.String()).To(HavePrefix("BEGIN:VCALENDAR\r\n"))
+		Expect(exported.String()).To(HaveSuffix("END:VCALENDAR\r\n"))
+	})
+
+	It("has one VEVENT per window", func() {
+		Expect(strings.Count(exported.String(), "BEGIN:VEVENT")).To(Equal(2))
+	})
+
+	It("describes the time zone", func() {
+		Expect(exported.String()).To(ContainSubstring("BEGIN:VTIMEZONE\r\nTZID:Europe/Berlin\r\n"))
+	})
+
+	It("has stable UIDs", func() {
+		Expect(exported.String()).To(ContainSubstring("UID:" + original.Windows[0].UID()))
+		Expect(original.Windows[0].UID()).To(Equal(original.Windows[0].UID()))
+		Expect(original.Windows[0].UID()).ToNot(Equal(original.Windows[1].UID()))
+	})
+
+	It("writes the scope as CATEGORIES", func() {
+		Expect(exported.String()).To(ContainSubstring("CATEGORIES:eu-de,us-east\r\n"))
+	})
+
+	Context("imported again", func() {
+		var imported *freeze.Calendar
+
+		JustBeforeEach(func() {
+			imported, err = freeze.LoadICalendar(strings.NewReader(exported.String()))
+		})
+
+		It("works", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("has the same windows", func() {
+			Expect(imported.Windows).To(HaveLen(len(original.Windows)))
+
+			for i, window := range imported.Windows {
+				Expect(window.Name).To(Equal(original.Windows[i].Name))
+				Expect(window.Start.Equal(original.Windows[i].Start)).To(BeTrue())
+				Expect(window.End.Equal(original.Windows[i].End)).To(BeTrue())
+				Expect(window.Scope).To(Equal(original.Windows[i].Scope))
+				Expect(window.Recurrence).To(Equal(original.Windows[i].Recurrence))
+				Expect(window.Except).To(HaveLen(len(original.Windows[i].Except)))
+			}
+		})
+	})
+
+	Context("windows with the same name and start in different scopes", func() {
+		BeforeEach(func() {
+			original, err = freeze.LoadCalendar(strings.NewReader(`
+freeze_calendar:
+  - name: Maintenance
+    starts_at: 2023-10-07T06:00:00Z
+    ends_at: 2023-10-07T18:00:00Z
+    scope: [eu-de]
+  - name: Maintenance
+    starts_at: 2023-10-07T06:00:00Z
+    ends_at: 2023-10-07T18:00:00Z
+    scope: [us-east]
+`))
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("has distinct UIDs", func() {
+			Expect(original.Windows[0].UID()).ToNot(Equal(original.Windows[1].UID()))
+		})
+	})
+
+	Context("of an iCalendar with a modified occurrence", func() {
+		BeforeEach(func() {
+			original, err = freeze.LoadICalendar(strings.NewReader(strings.Join([]string{
+				"BEGIN:VCALENDAR",
+				"BEGIN:VEVENT",
+				"UID:release-train",
+				"SUMMARY:Release train",
+				"DTSTART;TZID=Europe/Berlin:20231002T080000",
+				"DTEND;TZID=Europe/Berlin:20231002T100000",
+				"RRULE:FREQ=WEEKLY;COUNT=3",
+				"EXDATE;TZID=Europe/Berlin:20231016T080000",
+				"END:VEVENT",
+				"BEGIN:VEVENT",
+				"UID:release-train",
+				"RECURRENCE-ID;TZID=Europe/Berlin:20231009T080000",
+				"SUMMARY:Release train (moved)",
+				"DTSTART;TZID=Europe/Berlin:20231010T080000",
+				"DTEND;TZID=Europe/Berlin:20231010T100000",
+				"END:VEVENT",
+				"END:VCALENDAR",
+			}, "\n")))
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("writes the replacement with the UID of the recurring window and a RECURRENCE-ID", func() {
+			Expect(exported.String()).To(ContainSubstring("BEGIN:VEVENT\r\nUID:" + original.Windows[0].UID() + "\r\nRECURRENCE-ID;TZID=Europe/Berlin:20231009T080000\r\n"))
+			Expect(strings.Count(exported.String(), "UID:"+original.Windows[0].UID())).To(Equal(2))
+		})
+
+		It("excludes just the cancelled occurrence", func() {
+			Expect(exported.String()).To(ContainSubstring("EXDATE;TZID=Europe/Berlin:20231016T080000\r\n"))
+			Expect(exported.String()).ToNot(ContainSubstring("EXDATE;TZID=Europe/Berlin:20231009T080000"))
+		})
+
+		It("is imported again the same", func() {
+			imported, err := freeze.LoadICalendar(strings.NewReader(exported.String()))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(imported.Windows).To(HaveLen(2))
+			Expect(imported.Windows[0].Except).To(HaveLen(2))
+			Expect(imported.Windows[1].ReplacesOccurrence.Equal(original.Windows[1].ReplacesOccurrence)).To(BeTrue())
+		})
+	})
+})
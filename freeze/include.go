@@ -0,0 +1,146 @@
+package freeze
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Include refers to a calendar file in another repository (or at another ref)
+type Include struct {
+	URI  string `yaml:"uri" validate:"required"`
+	Ref  string `yaml:"ref,omitempty"` // branch, tag or commit; defaults to the default branch
+	Path string `yaml:"path" validate:"required"`
+}
+
+func (i Include) String() string {
+	if i.Ref == "" {
+		return fmt.Sprintf("%s in %s", i.Path, i.URI)
+	}
+
+	return fmt.Sprintf("%s in %s@%s", i.Path, i.URI, i.Ref)
+}
+
+// Fetcher provides the contents of included calendar files
+type Fetcher interface {
+	Fetch(include Include) (io.ReadCloser, error)
+}
+
+// LocalFetcher resolves includes against local directories (e.g. checkouts of the included repositories) by their URI,
+// ignoring their ref. It serves the command line, where includes are resolved offline.
+type LocalFetcher map[string]string
+
+func (f LocalFetcher) Fetch(include Include) (io.ReadCloser, error) {
+	dir, found := f[include.URI]
+
+	if !found {
+		return nil, fmt.Errorf("no local directory given for %s; use --include-path %s=DIR", include.URI, include.URI)
+	}
+
+	return os.Open(filepath.Join(dir, include.Path))
+}
+
+// LoadFile loads the calendar file at path and resolves its includes with fetcher
+func LoadFile(path string, fetcher Fetcher) (*Calendar, error) {
+	calendarFile, err := os.Open(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to read calendar file from path %s: %w", path, err)
+	}
+
+	defer calendarFile.Close()
+
+	calendar, err := Load(path, calendarFile)
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to load calendar: %w", err)
+	}
+
+	calendar, err = ResolveIncludes(calendar, fetcher)
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve includes: %w", err)
+	}
+
+	return calendar, nil
+}
+
+// ResolveIncludes loads the calendars included by c, and the ones included by those, and merges them into one
+// calendar without includes. Windows and overrides of included calendars are tagged with the include as their origin. Calendars included more than
+// once are merged only once; an include that (indirectly) includes itself is an error.
+func ResolveIncludes(c *Calendar, fetcher Fetcher) (*Calendar, error) {
+	return resolveIncludes(c, fetcher, nil, map[Include]bool{})
+}
+
+func resolveIncludes(c *Calendar, fetcher Fetcher, chain []Include, seen map[Include]bool) (*Calendar, error) {
+	calendars := []*Calendar{c}
+
+	for _, include := range c.Includes {
+		if slices.Contains(chain, include) {
+			var cycle []string
+
+			for _, link := range chain[slices.Index(chain, include):] {
+				cycle = append(cycle, link.String())
+			}
+
+			cycle = append(cycle, include.String())
+
+			return nil, fmt.Errorf("include cycle: %s", strings.Join(cycle, " -> "))
+		}
+
+		if seen[include] {
+			continue
+		}
+
+		seen[include] = true
+
+		included, err := fetchInclude(include, fetcher)
+
+		if err != nil {
+			return nil, err
+		}
+
+		included, err = resolveIncludes(included, fetcher, append(slices.Clone(chain), include), seen)
+
+		if err != nil {
+			return nil, err
+		}
+
+		calendars = append(calendars, included)
+	}
+
+	merged := Merge(calendars...)
+	merged.TimeZone = c.TimeZone
+	merged.Includes = nil // all resolved
+
+	return merged, nil
+}
+
+func fetchInclude(include Include, fetcher Fetcher) (*Calendar, error) {
+	reader, err := fetcher.Fetch(include)
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch included calendar %s: %w", include, err)
+	}
+
+	defer reader.Close()
+
+	calendar, err := Load(include.Path, reader)
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to load included calendar %s: %w", include, err)
+	}
+
+	for i := range calendar.Windows {
+		calendar.Windows[i].Origin = include.String()
+	}
+
+	for i := range calendar.Overrides {
+		calendar.Overrides[i].Origin = include.String()
+	}
+
+	return calendar, nil
+}
@@ -0,0 +1,148 @@
+package freeze_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/homeport/freeze-calendar-resource/freeze"
+)
+
+// files fetches includes from a map of "uri/path" to contents
+type files map[string]string
+
+func (f files) Fetch(include freeze.Include) (io.ReadCloser, error) {
+	content, found := f[include.URI+"/"+include.Path]
+
+	if !found {
+		return nil, errors.New("not found")
+	}
+
+	return io.NopCloser(strings.NewReader(content)), nil
+}
+
+var _ = Describe("Includes", func() {
+	var (
+		err      error
+		fetched  files
+		root     string
+		calendar *freeze.Calendar
+	)
+
+	BeforeEach(func() {
+		root = `
+include:
+  - uri: git@github.com:corp/freeze
+    ref: main
+    path: corporate.yaml
+freeze_calendar:
+  - name: Team Offsite
+    starts_at: 2023-09-01T00:00:00Z
+    ends_at: 2023-09-02T00:00:00Z
+`
+		fetched = files{
+			"git@github.com:corp/freeze/corporate.yaml": `
+timezone: Europe/Berlin
+include:
+  - uri: git@github.com:corp/holidays
+    path: holidays.yaml
+freeze_calendar:
+  - name: Year-end Closing
+    starts_at: 2023-12-29 18:00
+    ends_at: 2024-01-02 06:00
+overrides:
+  - reason: Hotfix for INC-4711
+    approver: jane.doe@example.com
+    expires_at: 2023-12-30T20:00:00Z
+`,
+			"git@github.com:corp/holidays/holidays.yaml": `
+freeze_calendar:
+  - name: Holiday Season
+    starts_at: 2023-12-01T06:00:00Z
+    ends_at: 2023-12-27T06:00:00Z
+`,
+		}
+	})
+
+	JustBeforeEach(func() {
+		var c *freeze.Calendar
+		c, err = freeze.Load("team.yaml", strings.NewReader(root))
+		Expect(err).ToNot(HaveOccurred())
+
+		calendar, err = freeze.ResolveIncludes(c, fetched)
+	})
+
+	It("merges included calendars recursively", func() {
+		Expect(err).ToNot(HaveOccurred())
+		Expect(calendar.Windows).To(HaveLen(3))
+		Expect(calendar.Overrides).To(HaveLen(1))
+		Expect(calendar.Includes).To(BeEmpty())
+	})
+
+	It("tags included windows with the include", func() {
+		Expect(calendar.Windows[0].Origin).To(Equal("team.yaml"))
+		Expect(calendar.Windows[1].Origin).To(Equal("corporate.yaml in git@github.com:corp/freeze@main"))
+		Expect(calendar.Windows[2].Origin).To(Equal("holidays.yaml in git@github.com:corp/holidays"))
+	})
+
+	It("applies the time zone of the included calendar", func() {
+		Expect(calendar.Windows[1].Start.Location().String()).To(Equal("Europe/Berlin"))
+	})
+
+	Context("calendar included twice", func() {
+		BeforeEach(func() {
+			root = `
+include:
+  - uri: git@github.com:corp/freeze
+    ref: main
+    path: corporate.yaml
+  - uri: git@github.com:corp/holidays
+    path: holidays.yaml
+freeze_calendar: []
+`
+		})
+
+		It("merges it once", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calendar.Windows).To(HaveLen(2))
+		})
+	})
+
+	Context("cycle", func() {
+		BeforeEach(func() {
+			fetched["git@github.com:corp/holidays/holidays.yaml"] = `
+include:
+  - uri: git@github.com:corp/freeze
+    ref: main
+    path: corporate.yaml
+freeze_calendar: []
+`
+		})
+
+		It("fails", func() {
+			Expect(err).To(MatchError("include cycle: corporate.yaml in git@github.com:corp/freeze@main -> holidays.yaml in git@github.com:corp/holidays -> corporate.yaml in git@github.com:corp/freeze@main"))
+		})
+	})
+
+	Context("include that cannot be fetched", func() {
+		BeforeEach(func() {
+			delete(fetched, "git@github.com:corp/holidays/holidays.yaml")
+		})
+
+		It("fails", func() {
+			Expect(err).To(MatchError(ContainSubstring("unable to fetch included calendar holidays.yaml in git@github.com:corp/holidays: not found")))
+		})
+	})
+
+	It("requires the path of an include", func() {
+		_, err := freeze.LoadCalendar(strings.NewReader(`
+include:
+  - uri: git@github.com:corp/freeze
+freeze_calendar: []
+`))
+		Expect(err).To(MatchError(ContainSubstring("'Path' failed on the 'required' tag")))
+	})
+})
@@ -0,0 +1,107 @@
+package freeze
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Override lets get pass through active freeze windows until it expires, e.g. for an emergency deployment.
+// An override without scope applies to all scopes; one without team, pipeline or job to all builds.
+type Override struct {
+	Reason    string    `yaml:"reason" validate:"required"`
+	Approver  string    `yaml:"approver" validate:"required"`
+	ExpiresAt time.Time `yaml:"expires_at" validate:"required"`
+	Scope     []string  `yaml:"scope,omitempty"`
+	Team      string    `yaml:"team,omitempty"`
+	Pipeline  string    `yaml:"pipeline,omitempty"`
+	Job       string    `yaml:"job,omitempty"`
+	Origin    string    `yaml:"-"` // the file the override was loaded from, if any
+
+	positions positions
+
+	// whether expires_at was given without an offset and is to be resolved against the calendar's time zone
+	floatingExpiry bool
+}
+
+// Build identifies the build that evaluates the calendar, as provided by Concourse in the BUILD_* environment variables
+type Build struct {
+	Team     string
+	Pipeline string
+	Job      string
+}
+
+type plainOverride Override
+
+func (o *Override) UnmarshalYAML(node *yaml.Node) error {
+	rest, timestamps := splitMapping(node, "expires_at")
+	err := rest.Decode((*plainOverride)(o))
+
+	if err != nil {
+		return err
+	}
+
+	o.positions = positionsOf(node)
+	o.ExpiresAt, o.floatingExpiry, err = parseTimestamp(timestamps["expires_at"])
+
+	if err != nil {
+		return errorAt(timestamps["expires_at"], fmt.Errorf("unable to parse expires_at: %w", err))
+	}
+
+	return nil
+}
+
+// Position returns where the first of keys that is defined (e.g. scope) was given in the calendar file, or where the
+// override itself was. It is zero for overrides that were not loaded from YAML.
+func (o Override) Position(keys ...string) Position {
+	return o.positions.of(keys...)
+}
+
+func (o Override) String() (result string) {
+	result = fmt.Sprintf("approved by %s until %s: %s", o.Approver, o.ExpiresAt, o.Reason)
+
+	var restrictions []string
+
+	if len(o.Scope) > 0 {
+		restrictions = append(restrictions, "scope: "+strings.Join(o.Scope, ", "))
+	}
+
+	for _, r := range []struct{ name, value string }{{"team", o.Team}, {"pipeline", o.Pipeline}, {"job", o.Job}} {
+		if r.value != "" {
+			restrictions = append(restrictions, r.name+": "+r.value)
+		}
+	}
+
+	if len(restrictions) > 0 {
+		result += fmt.Sprintf(" (%s)", strings.Join(restrictions, "; "))
+	}
+
+	return
+}
+
+// Matches tells whether the override has not expired at the given time and covers the scope and build. A scoped
+// override only covers requests that are restricted to scopes within the override's scope (see ScopeCovers).
+func (o Override) Matches(at time.Time, scope []string, build Build) bool {
+	if at.After(o.ExpiresAt) {
+		return false
+	}
+
+	if o.Team != "" && o.Team != build.Team || o.Pipeline != "" && o.Pipeline != build.Pipeline || o.Job != "" && o.Job != build.Job {
+		return false
+	}
+
+	return ScopeCovers(o.Scope, scope)
+}
+
+// Override returns the first override that matches, if any
+func (c Calendar) Override(at time.Time, scope []string, build Build) (Override, bool) {
+	for _, o := range c.Overrides {
+		if o.Matches(at, scope, build) {
+			return o, true
+		}
+	}
+
+	return Override{}, false
+}
@@ -0,0 +1,64 @@
+package freeze_test
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/homeport/freeze-calendar-resource/freeze"
+)
+
+var _ = Describe("Override", func() {
+	var (
+		err      error
+		calendar *freeze.Calendar
+	)
+
+	BeforeEach(func() {
+		calendar, err = freeze.LoadCalendar(strings.NewReader(`
+timezone: Europe/Berlin
+freeze_calendar: []
+overrides:
+  - reason: Hotfix for INC-4711
+    approver: jane.doe@example.com
+    expires_at: 2023-08-11T22:00:00
+    scope: [eu-de, eu-nl]
+    pipeline: shop
+`))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("resolves the expiry against the calendar's time zone", func() {
+		Expect(calendar.Overrides[0].ExpiresAt.Equal(time.Date(2023, time.August, 11, 20, 0, 0, 0, time.UTC))).To(BeTrue())
+	})
+
+	DescribeTable("matching",
+		func(at time.Time, scope []string, build freeze.Build, expected bool) {
+			_, found := calendar.Override(at, scope, build)
+			Expect(found).To(Equal(expected))
+		},
+		Entry("before expiry", time.Date(2023, time.August, 11, 19, 0, 0, 0, time.UTC), []string{"eu-de"}, freeze.Build{Pipeline: "shop"}, true),
+		Entry("at expiry", time.Date(2023, time.August, 11, 20, 0, 0, 0, time.UTC), []string{"eu-de"}, freeze.Build{Pipeline: "shop"}, true),
+		Entry("after expiry", time.Date(2023, time.August, 11, 20, 0, 1, 0, time.UTC), []string{"eu-de"}, freeze.Build{Pipeline: "shop"}, false),
+		Entry("all requested scopes covered", time.Date(2023, time.August, 11, 19, 0, 0, 0, time.UTC), []string{"eu-de", "eu-nl"}, freeze.Build{Pipeline: "shop"}, true),
+		Entry("requested scope not covered", time.Date(2023, time.August, 11, 19, 0, 0, 0, time.UTC), []string{"eu-de", "us-east"}, freeze.Build{Pipeline: "shop"}, false),
+		Entry("request without scope", time.Date(2023, time.August, 11, 19, 0, 0, 0, time.UTC), nil, freeze.Build{Pipeline: "shop"}, false),
+		Entry("other pipeline", time.Date(2023, time.August, 11, 19, 0, 0, 0, time.UTC), []string{"eu-de"}, freeze.Build{Pipeline: "warehouse"}, false),
+	)
+
+	Context("without approver", func() {
+		BeforeEach(func() {
+			_, err = freeze.LoadCalendar(strings.NewReader(`
+overrides:
+  - reason: YOLO
+    expires_at: 2023-08-11T22:00:00Z
+`))
+		})
+
+		It("fails", func() {
+			Expect(err).To(MatchError(ContainSubstring("validation for 'Approver' failed")))
+		})
+	})
+})
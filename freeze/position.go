@@ -0,0 +1,244 @@
+package freeze
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/homeport/freeze-calendar-resource/keys"
+	"go.yaml.in/yaml/v3"
+)
+
+// Position is where something is defined in a YAML calendar file. Lines and columns start at 1; the zero value stands
+// for an unknown position, e.g. of windows loaded from iCalendar files.
+type Position struct {
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+}
+
+func (p Position) String() string {
+	if p.Column == 0 {
+		return fmt.Sprintf("line %d", p.Line)
+	}
+
+	return fmt.Sprintf("line %d, column %d", p.Line, p.Column)
+}
+
+func positionOf(node *yaml.Node) Position {
+	if node == nil {
+		return Position{}
+	}
+
+	return Position{Line: node.Line, Column: node.Column}
+}
+
+// positions maps the keys of a mapping to the position of their values; the empty key to the mapping itself
+type positions map[string]Position
+
+func positionsOf(node *yaml.Node) positions {
+	result := positions{"": positionOf(node)}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		result[node.Content[i].Value] = positionOf(node.Content[i+1])
+	}
+
+	return result
+}
+
+// of returns the position of the first of keys that is defined, or of the mapping itself if none is
+func (p positions) of(keys ...string) Position {
+	for _, key := range keys {
+		if position, found := p[key]; found {
+			return position
+		}
+	}
+
+	return p[""]
+}
+
+// error locates err at the first of keys that is defined, or at the mapping itself; unless its position is unknown
+func (p positions) error(err error, keys ...string) error {
+	position := p.of(keys...)
+
+	if position.Line == 0 {
+		return err
+	}
+
+	return PositionError{Position: position, Err: err}
+}
+
+// PositionError is an error at a known position of a calendar file
+type PositionError struct {
+	Position
+	Err error
+}
+
+func (e PositionError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Position, e.Err)
+}
+
+func (e PositionError) Unwrap() error {
+	return e.Err
+}
+
+// errorAt returns a PositionError at node, unless its position is unknown
+func errorAt(node *yaml.Node, err error) error {
+	if node == nil || node.Line == 0 {
+		return err
+	}
+
+	return PositionError{Position: positionOf(node), Err: err}
+}
+
+var yamlLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// yamlErrors splits errors of the YAML decoder into PositionErrors, e.g. one per type mismatch
+func yamlErrors(err error) error {
+	var typeError *yaml.TypeError
+	messages := []string{err.Error()}
+
+	if errors.As(err, &typeError) {
+		messages = typeError.Errors
+	}
+
+	var result []error
+
+	for _, message := range messages {
+		match := yamlLine.FindStringSubmatch(message)
+
+		if match == nil {
+			return err
+		}
+
+		line, _ := strconv.Atoi(match[1])
+		result = append(result, PositionError{Position: Position{Line: line}, Err: errors.New(match[2])})
+	}
+
+	return errors.Join(result...)
+}
+
+// validationErrors locates each failed validation of the calendar in document, its YAML source, if given
+func validationErrors(err error, document *yaml.Node) error {
+	var fieldErrors validator.ValidationErrors
+
+	if document == nil || !errors.As(err, &fieldErrors) {
+		return err
+	}
+
+	var result []error
+
+	for _, fieldError := range fieldErrors {
+		result = append(result, errorAt(lookup(document, reflect.TypeOf(Calendar{}), fieldError.StructNamespace()), fieldError))
+	}
+
+	return errors.Join(result...)
+}
+
+var namespaceSegment = regexp.MustCompile(`^(\w+)(?:\[(\d+)\])?$`)
+
+// lookup finds the node of a struct namespace like Calendar.Windows[0].End, following the yaml tags of typ. If the
+// field is missing, the closest node that exists is returned.
+func lookup(node *yaml.Node, typ reflect.Type, namespace string) *yaml.Node {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	segments := strings.Split(namespace, ".")[1:] // the first one is the type itself
+
+	for _, segment := range segments {
+		match := namespaceSegment.FindStringSubmatch(segment)
+
+		for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice {
+			typ = typ.Elem()
+		}
+
+		if match == nil || typ.Kind() != reflect.Struct || node.Kind != yaml.MappingNode {
+			return node
+		}
+
+		field, found := typ.FieldByName(match[1])
+
+		if !found {
+			return node
+		}
+
+		key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		value := valueOf(node, key)
+
+		if value == nil {
+			return node
+		}
+
+		node, typ = value, field.Type
+
+		if match[2] != "" {
+			index, _ := strconv.Atoi(match[2])
+
+			if node.Kind != yaml.SequenceNode || index >= len(node.Content) {
+				return node
+			}
+
+			node = node.Content[index]
+		}
+	}
+
+	return node
+}
+
+// unknownKeys returns a keys.UnknownError at each key of node that typ does not know, e.g. end_at instead of ends_at
+func unknownKeys(node *yaml.Node, typ reflect.Type, path string) []error {
+	var errs []error
+
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		if node.Kind == yaml.SequenceNode && typ.Kind() != reflect.Slice {
+			return nil
+		}
+
+		for i, item := range node.Content {
+			elementPath, elementType := path, typ
+
+			if node.Kind == yaml.SequenceNode {
+				elementPath, elementType = fmt.Sprintf("%s[%d]", path, i), typ.Elem()
+			}
+
+			errs = append(errs, unknownKeys(item, elementType, elementPath)...)
+		}
+	case yaml.MappingNode:
+		structType := keys.Struct(typ)
+
+		if structType == nil || typ.Kind() == reflect.Slice {
+			return nil
+		}
+
+		known := keys.Of(structType, "yaml")
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			fieldType, found := known[key]
+
+			if !found {
+				errs = append(errs, errorAt(node.Content[i], keys.UnknownError{Path: path, Key: key, Known: keys.Sorted(known)}))
+				continue
+			}
+
+			errs = append(errs, unknownKeys(node.Content[i+1], fieldType, strings.TrimPrefix(path+"."+key, "."))...)
+		}
+	}
+
+	return errs
+}
+
+func valueOf(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+
+	return nil
+}
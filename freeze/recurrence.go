@@ -0,0 +1,460 @@
+package freeze
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+type Frequency string
+
+const (
+	Daily   = Frequency("DAILY")
+	Weekly  = Frequency("WEEKLY")
+	Monthly = Frequency("MONTHLY")
+	Yearly  = Frequency("YEARLY")
+)
+
+// A recurrence without any occurrence for this long is considered exhausted.
+// This prevents endless loops on rules that can never match, e.g. BYMONTH=2;BYMONTHDAY=30.
+const recurrenceHorizon = 10 * 366 * 24 * time.Hour
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// WeekdayNum is a BYDAY entry like FR, 1MO or -1FR. An ordinal of zero means every such weekday in the period.
+type WeekdayNum struct {
+	Ordinal int
+	Weekday time.Weekday
+}
+
+func (wd WeekdayNum) String() string {
+	for name, weekday := range weekdays {
+		if weekday == wd.Weekday {
+			if wd.Ordinal == 0 {
+				return name
+			}
+
+			return fmt.Sprintf("%d%s", wd.Ordinal, name)
+		}
+	}
+
+	return ""
+}
+
+// Recurrence is the subset of an iCalendar RRULE (RFC 5545, section 3.3.10) that is relevant for freeze windows.
+type Recurrence struct {
+	Frequency  Frequency
+	Interval   int
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	Until      time.Time
+	Count      int
+
+	// whether UNTIL was given without a trailing Z and is to be resolved against the window's time zone
+	floatingUntil bool
+}
+
+func ParseRecurrence(rule string) (*Recurrence, error) {
+	r := Recurrence{Interval: 1}
+
+	for part := range strings.SplitSeq(strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:"), ";") {
+		if part == "" {
+			continue
+		}
+
+		key, value, found := strings.Cut(part, "=")
+
+		if !found {
+			return nil, fmt.Errorf("rule part '%s' is not of the form KEY=VALUE", part)
+		}
+
+		var err error
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.Frequency = Frequency(strings.ToUpper(value))
+
+			if !slices.Contains([]Frequency{Daily, Weekly, Monthly, Yearly}, r.Frequency) {
+				return nil, fmt.Errorf("unsupported frequency %s; supported are %s, %s, %s and %s", value, Daily, Weekly, Monthly, Yearly)
+			}
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+
+			if err == nil && r.Interval < 1 {
+				err = fmt.Errorf("interval must be positive")
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+
+			if err == nil && r.Count < 1 {
+				err = fmt.Errorf("count must be positive")
+			}
+		case "UNTIL":
+			r.Until, err = parseICalendarTime(value, time.UTC)
+			r.floatingUntil = !strings.HasSuffix(value, "Z")
+		case "BYDAY":
+			r.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseNumberList(value, -31, 31)
+		case "BYMONTH":
+			var months []int
+			months, err = parseNumberList(value, 1, 12)
+
+			for _, m := range months {
+				r.ByMonth = append(r.ByMonth, time.Month(m))
+			}
+		case "WKST":
+			if strings.ToUpper(value) != "MO" {
+				err = fmt.Errorf("only MO is supported as start of the week")
+			}
+		default:
+			return nil, fmt.Errorf("unsupported rule part %s", key)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s '%s': %w", key, value, err)
+		}
+	}
+
+	if r.Frequency == "" {
+		return nil, fmt.Errorf("rule '%s' has no FREQ", rule)
+	}
+
+	if r.Count != 0 && !r.Until.IsZero() {
+		return nil, fmt.Errorf("rule '%s' must not have both COUNT and UNTIL", rule)
+	}
+
+	for _, wd := range r.ByDay {
+		if wd.Ordinal == 0 {
+			continue
+		}
+
+		if r.Frequency != Monthly && (r.Frequency != Yearly || len(r.ByMonth) == 0) {
+			return nil, fmt.Errorf("rule '%s' has a numbered BYDAY, which is only supported with FREQ=MONTHLY or FREQ=YEARLY;BYMONTH=...", rule)
+		}
+	}
+
+	return &r, nil
+}
+
+func (r Recurrence) String() string {
+	parts := []string{"FREQ=" + string(r.Frequency)}
+
+	if r.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.Interval))
+	}
+
+	if len(r.ByMonth) > 0 {
+		parts = append(parts, "BYMONTH="+joinNumbers(r.ByMonth))
+	}
+
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinNumbers(r.ByMonthDay))
+	}
+
+	if len(r.ByDay) > 0 {
+		var days []string
+
+		for _, wd := range r.ByDay {
+			days = append(days, wd.String())
+		}
+
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+
+	if r.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", r.Count))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+func (r *Recurrence) UnmarshalYAML(node *yaml.Node) error {
+	var rule string
+
+	if err := node.Decode(&rule); err != nil {
+		return err
+	}
+
+	parsed, err := ParseRecurrence(rule)
+
+	if err != nil {
+		return errorAt(node, err)
+	}
+
+	*r = *parsed
+	return nil
+}
+
+func (r Recurrence) MarshalYAML() (any, error) {
+	return r.String(), nil
+}
+
+// Starts yields the start of each occurrence, beginning with the first one at or after dtstart.
+// The time of day of dtstart is kept in its location, so that occurrences follow the wall clock across DST changes.
+func (r Recurrence) Starts(dtstart time.Time) iter.Seq[time.Time] {
+	return func(yield func(time.Time) bool) {
+		interval := max(r.Interval, 1)
+		last := dtstart
+		count := 0
+
+		for period := 0; ; period += interval {
+			periodStart, candidates := r.candidates(dtstart, period)
+
+			if periodStart.Sub(last) > recurrenceHorizon {
+				return
+			}
+
+			for _, candidate := range candidates {
+				if candidate.Before(dtstart) {
+					continue
+				}
+
+				if !r.Until.IsZero() && candidate.After(r.Until) {
+					return
+				}
+
+				if !yield(candidate) {
+					return
+				}
+
+				last = candidate
+				count++
+
+				if r.Count > 0 && count >= r.Count {
+					return
+				}
+			}
+		}
+	}
+}
+
+// candidates returns the beginning of the given period (counted in units of the frequency from dtstart) and all occurrences within it, in chronological order
+func (r Recurrence) candidates(dtstart time.Time, period int) (time.Time, []time.Time) {
+	year, month, day := dtstart.Date()
+	hour, minute, second := dtstart.Clock()
+	loc := dtstart.Location()
+
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hour, minute, second, dtstart.Nanosecond(), loc)
+	}
+
+	var days []time.Time
+	var periodStart time.Time
+
+	switch r.Frequency {
+	case Daily:
+		periodStart = at(year, month, day+period)
+
+		if r.matchesMonth(periodStart.Month()) && r.matchesMonthDay(periodStart) && r.matchesWeekday(periodStart) {
+			days = append(days, periodStart)
+		}
+	case Weekly:
+		monday := day - (int(dtstart.Weekday())+6)%7
+		periodStart = at(year, month, monday+7*period)
+
+		for offset := range 7 {
+			candidate := at(year, month, monday+7*period+offset)
+
+			if len(r.ByDay) == 0 && candidate.Weekday() != dtstart.Weekday() {
+				continue
+			}
+
+			if r.matchesMonth(candidate.Month()) && r.matchesMonthDay(candidate) && r.matchesWeekday(candidate) {
+				days = append(days, candidate)
+			}
+		}
+	case Monthly:
+		periodStart = at(year, month+time.Month(period), 1)
+
+		if r.matchesMonth(periodStart.Month()) {
+			days = r.daysOfMonth(periodStart.Year(), periodStart.Month(), day, at)
+		}
+	case Yearly:
+		periodStart = at(year+period, time.January, 1)
+
+		if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 && len(r.ByMonth) == 0 {
+			days = r.daysOfMonth(year+period, month, day, at)
+			break
+		}
+
+		months := r.sortedMonths()
+
+		if len(months) == 0 {
+			for m := time.January; m <= time.December; m++ {
+				months = append(months, m)
+			}
+		}
+
+		for _, m := range months {
+			days = append(days, r.daysOfMonth(year+period, m, day, at)...)
+		}
+	}
+
+	return periodStart, days
+}
+
+// daysOfMonth returns all occurrences in the given month, or the day of dtstart if neither BYMONTHDAY nor BYDAY is given
+func (r Recurrence) daysOfMonth(year int, month time.Month, dtstartDay int, at func(int, time.Month, int) time.Time) []time.Time {
+	length := at(year, month+1, 0).Day()
+
+	var result []time.Time
+
+	for d := 1; d <= length; d++ {
+		candidate := at(year, month, d)
+
+		if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+			if d == dtstartDay {
+				result = append(result, candidate)
+			}
+
+			continue
+		}
+
+		if !r.matchesMonthDay(candidate) {
+			continue
+		}
+
+		if len(r.ByDay) > 0 && !slices.ContainsFunc(r.ByDay, func(wd WeekdayNum) bool {
+			if wd.Weekday != candidate.Weekday() {
+				return false
+			}
+
+			switch {
+			case wd.Ordinal > 0:
+				return (d-1)/7+1 == wd.Ordinal
+			case wd.Ordinal < 0:
+				return (length-d)/7+1 == -wd.Ordinal
+			default:
+				return true
+			}
+		}) {
+			continue
+		}
+
+		result = append(result, candidate)
+	}
+
+	return result
+}
+
+func (r Recurrence) matchesMonth(m time.Month) bool {
+	return len(r.ByMonth) == 0 || slices.Contains(r.ByMonth, m)
+}
+
+func (r Recurrence) matchesMonthDay(t time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+
+	length := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+	return slices.ContainsFunc(r.ByMonthDay, func(md int) bool {
+		return md == t.Day() || md < 0 && length+md+1 == t.Day()
+	})
+}
+
+func (r Recurrence) matchesWeekday(t time.Time) bool {
+	return len(r.ByDay) == 0 || slices.ContainsFunc(r.ByDay, func(wd WeekdayNum) bool {
+		return wd.Weekday == t.Weekday()
+	})
+}
+
+func (r Recurrence) sortedMonths() []time.Month {
+	months := slices.Clone(r.ByMonth)
+	slices.Sort(months)
+
+	return slices.Compact(months)
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var result []WeekdayNum
+
+	for entry := range strings.SplitSeq(strings.ToUpper(value), ",") {
+		if len(entry) < 2 {
+			return nil, fmt.Errorf("'%s' is not a weekday", entry)
+		}
+
+		weekday, found := weekdays[entry[len(entry)-2:]]
+
+		if !found {
+			return nil, fmt.Errorf("'%s' is not a weekday", entry)
+		}
+
+		wd := WeekdayNum{Weekday: weekday}
+
+		if prefix := entry[:len(entry)-2]; prefix != "" {
+			ordinal, err := strconv.Atoi(prefix)
+
+			if err != nil || ordinal == 0 || ordinal < -5 || ordinal > 5 {
+				return nil, fmt.Errorf("'%s' has an invalid ordinal", entry)
+			}
+
+			wd.Ordinal = ordinal
+		}
+
+		result = append(result, wd)
+	}
+
+	return result, nil
+}
+
+func parseNumberList(value string, minimum, maximum int) ([]int, error) {
+	var result []int
+
+	for entry := range strings.SplitSeq(value, ",") {
+		n, err := strconv.Atoi(entry)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if n == 0 || n < minimum || n > maximum {
+			return nil, fmt.Errorf("%d is out of range [%d, %d]", n, minimum, maximum)
+		}
+
+		result = append(result, n)
+	}
+
+	return result, nil
+}
+
+func joinNumbers[T ~int](numbers []T) string {
+	var result []string
+
+	for _, n := range numbers {
+		result = append(result, strconv.Itoa(int(n)))
+	}
+
+	return strings.Join(result, ",")
+}
+
+// parseICalendarTime parses DATE-TIME and DATE values as used by RRULE's UNTIL. Values without a trailing Z are interpreted in loc.
+func parseICalendarTime(value string, loc *time.Location) (time.Time, error) {
+	switch {
+	case strings.HasSuffix(value, "Z"):
+		return time.Parse("20060102T150405Z", value)
+	case len(value) == len("20060102"):
+		return time.ParseInLocation("20060102", value, loc)
+	default:
+		return time.ParseInLocation("20060102T150405", value, loc)
+	}
+}
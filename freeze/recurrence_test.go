@@ -0,0 +1,256 @@
+package freeze_test
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/homeport/freeze-calendar-resource/freeze"
+)
+
+var _ = Describe("Recurrence", func() {
+	var (
+		err        error
+		rule       string
+		recurrence *freeze.Recurrence
+		dtstart    time.Time
+	)
+
+	starts := func(n int) []time.Time {
+		var result []time.Time
+
+		for start := range recurrence.Starts(dtstart) {
+			if len(result) >= n {
+				break
+			}
+
+			result = append(result, start)
+		}
+
+		return result
+	}
+
+	JustBeforeEach(func() {
+		recurrence, err = freeze.ParseRecurrence(rule)
+	})
+
+	Context("every Friday", func() {
+		BeforeEach(func() {
+			rule = "FREQ=WEEKLY;BYDAY=FR"
+			dtstart = time.Date(2023, time.September, 1, 15, 0, 0, 0, time.UTC) // a Friday
+		})
+
+		It("works", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("yields consecutive Fridays", func() {
+			Expect(starts(3)).To(HaveExactElements(
+				time.Date(2023, time.September, 1, 15, 0, 0, 0, time.UTC),
+				time.Date(2023, time.September, 8, 15, 0, 0, 0, time.UTC),
+				time.Date(2023, time.September, 15, 15, 0, 0, 0, time.UTC),
+			))
+		})
+
+		It("renders back to the same rule", func() {
+			Expect(recurrence.String()).To(Equal(rule))
+		})
+
+		Context("with a start on a Wednesday", func() {
+			BeforeEach(func() {
+				dtstart = time.Date(2023, time.August, 30, 15, 0, 0, 0, time.UTC)
+			})
+
+			It("begins with the following Friday", func() {
+				Expect(starts(1)).To(HaveExactElements(time.Date(2023, time.September, 1, 15, 0, 0, 0, time.UTC)))
+			})
+		})
+
+		Context("across a DST change", func() {
+			BeforeEach(func() {
+				berlin, err := time.LoadLocation("Europe/Berlin")
+				Expect(err).ToNot(HaveOccurred())
+				dtstart = time.Date(2023, time.October, 20, 15, 0, 0, 0, berlin)
+			})
+
+			It("keeps the wall clock time", func() {
+				for _, start := range starts(3) {
+					Expect(start.Hour()).To(Equal(15))
+				}
+			})
+		})
+	})
+
+	Context("every other Monday and Tuesday", func() {
+		BeforeEach(func() {
+			rule = "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TU"
+			dtstart = time.Date(2023, time.September, 4, 6, 0, 0, 0, time.UTC) // a Monday
+		})
+
+		It("skips every other week", func() {
+			Expect(starts(4)).To(HaveExactElements(
+				time.Date(2023, time.September, 4, 6, 0, 0, 0, time.UTC),
+				time.Date(2023, time.September, 5, 6, 0, 0, 0, time.UTC),
+				time.Date(2023, time.September, 18, 6, 0, 0, 0, time.UTC),
+				time.Date(2023, time.September, 19, 6, 0, 0, 0, time.UTC),
+			))
+		})
+	})
+
+	Context("monthly payroll", func() {
+		BeforeEach(func() {
+			rule = "FREQ=MONTHLY;BYMONTHDAY=25,-1"
+			dtstart = time.Date(2024, time.January, 25, 0, 0, 0, 0, time.UTC)
+		})
+
+		It("yields the 25th and the last day of each month", func() {
+			Expect(starts(4)).To(HaveExactElements(
+				time.Date(2024, time.January, 25, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, time.February, 25, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+			))
+		})
+	})
+
+	Context("last Friday of the month", func() {
+		BeforeEach(func() {
+			rule = "FREQ=MONTHLY;BYDAY=-1FR;COUNT=2"
+			dtstart = time.Date(2023, time.September, 1, 12, 0, 0, 0, time.UTC)
+		})
+
+		It("yields the last Fridays until COUNT is exhausted", func() {
+			Expect(starts(5)).To(HaveExactElements(
+				time.Date(2023, time.September, 29, 12, 0, 0, 0, time.UTC),
+				time.Date(2023, time.October, 27, 12, 0, 0, 0, time.UTC),
+			))
+		})
+	})
+
+	Context("Thanksgiving", func() {
+		BeforeEach(func() {
+			rule = "FREQ=YEARLY;BYMONTH=11;BYDAY=4TH"
+			dtstart = time.Date(2023, time.November, 1, 0, 0, 0, 0, time.UTC)
+		})
+
+		It("yields the fourth Thursday of November", func() {
+			Expect(starts(2)).To(HaveExactElements(
+				time.Date(2023, time.November, 23, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, time.November, 28, 0, 0, 0, 0, time.UTC),
+			))
+		})
+	})
+
+	Context("daily until a given date", func() {
+		BeforeEach(func() {
+			rule = "FREQ=DAILY;UNTIL=20231203T000000Z"
+			dtstart = time.Date(2023, time.December, 1, 0, 0, 0, 0, time.UTC)
+		})
+
+		It("stops at UNTIL", func() {
+			Expect(slices.Collect(recurrence.Starts(dtstart))).To(HaveLen(3))
+		})
+	})
+
+	Context("a rule that never matches", func() {
+		BeforeEach(func() {
+			rule = "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30"
+			dtstart = time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+		})
+
+		It("terminates", func() {
+			Expect(slices.Collect(recurrence.Starts(dtstart))).To(BeEmpty())
+		})
+	})
+
+	DescribeTable("invalid rules",
+		func(rule, message string) {
+			_, err := freeze.ParseRecurrence(rule)
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("without FREQ", "BYDAY=FR", "has no FREQ"),
+		Entry("unknown FREQ", "FREQ=HOURLY", "unsupported frequency"),
+		Entry("unknown weekday", "FREQ=WEEKLY;BYDAY=XY", "is not a weekday"),
+		Entry("COUNT and UNTIL", "FREQ=DAILY;COUNT=2;UNTIL=20231203T000000Z", "must not have both"),
+		Entry("numbered weekly BYDAY", "FREQ=WEEKLY;BYDAY=1FR", "numbered BYDAY"),
+		Entry("unsupported part", "FREQ=DAILY;BYHOUR=5", "unsupported rule part"),
+	)
+})
+
+var _ = Describe("Recurring window", func() {
+	var (
+		err      error
+		calendar *freeze.Calendar
+		window   freeze.Window
+	)
+
+	BeforeEach(func() {
+		calendar, err = freeze.LoadCalendar(strings.NewReader(`
+freeze_calendar:
+  - name: Weekend
+    starts_at: 2023-09-01T15:00:00Z
+    duration: 63h
+    recurrence: FREQ=WEEKLY;BYDAY=FR
+`))
+		Expect(err).ToNot(HaveOccurred())
+		window = calendar.Windows[0]
+	})
+
+	It("has its end computed from the duration", func() {
+		Expect(window.End).To(Equal(time.Date(2023, time.September, 4, 6, 0, 0, 0, time.UTC)))
+	})
+
+	It("is active on a later weekend", func() {
+		occurrence, active := window.ActiveAt(time.Date(2023, time.October, 8, 12, 0, 0, 0, time.UTC))
+		Expect(active).To(BeTrue())
+		Expect(occurrence.Start).To(Equal(time.Date(2023, time.October, 6, 15, 0, 0, 0, time.UTC)))
+		Expect(occurrence.End).To(Equal(time.Date(2023, time.October, 9, 6, 0, 0, 0, time.UTC)))
+	})
+
+	It("is not active during the week", func() {
+		_, active := window.ActiveAt(time.Date(2023, time.October, 11, 12, 0, 0, 0, time.UTC))
+		Expect(active).To(BeFalse())
+	})
+
+	It("lists upcoming occurrences", func() {
+		upcoming := window.Upcoming(time.Date(2023, time.October, 8, 12, 0, 0, 0, time.UTC), 2)
+		Expect(upcoming).To(HaveLen(2))
+		Expect(upcoming[0].Start).To(Equal(time.Date(2023, time.October, 6, 15, 0, 0, 0, time.UTC)))
+		Expect(upcoming[1].Start).To(Equal(time.Date(2023, time.October, 13, 15, 0, 0, 0, time.UTC)))
+	})
+
+	Context("with both ends_at and duration", func() {
+		BeforeEach(func() {
+			_, err = freeze.LoadCalendar(strings.NewReader(`
+freeze_calendar:
+  - name: Ambiguous
+    starts_at: 2023-09-01T15:00:00Z
+    ends_at: 2023-09-04T06:00:00Z
+    duration: 63h
+`))
+		})
+
+		It("fails", func() {
+			Expect(err).To(MatchError(ContainSubstring("validation for 'End' failed")))
+		})
+	})
+
+	Context("with an invalid rule", func() {
+		BeforeEach(func() {
+			_, err = freeze.LoadCalendar(strings.NewReader(`
+freeze_calendar:
+  - name: Broken
+    starts_at: 2023-09-01T15:00:00Z
+    duration: 1h
+    recurrence: FREQ=SOMETIMES
+`))
+		})
+
+		It("fails", func() {
+			Expect(err).To(MatchError(ContainSubstring("unsupported frequency")))
+		})
+	})
+})
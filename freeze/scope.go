@@ -0,0 +1,155 @@
+package freeze
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Scopes are lists of patterns. A pattern may contain globs as understood by path.Match (e.g. eu-* or *-prod), and
+// it covers hierarchical scopes below it (eu covers eu/de/frankfurt). A pattern prefixed with ! excludes what it
+// covers. A list without any including pattern covers everything that is not excluded.
+//
+// Patterns are compared with each other, not expanded: two patterns overlap if one of them covers the other.
+
+// ScopesOverlap tells whether a window restricted to windowScope applies to a request restricted to requestScope
+func ScopesOverlap(windowScope, requestScope []string) bool {
+	windowIncludes, windowExcludes := splitScope(windowScope)
+	requestIncludes, requestExcludes := splitScope(requestScope)
+
+	for _, r := range everythingIfEmpty(requestIncludes) {
+		if r != "" && covered(windowExcludes, r) {
+			continue
+		}
+
+		for _, w := range everythingIfEmpty(windowIncludes) {
+			if w != "" && r != "" && !covers(w, r) && !covers(r, w) {
+				continue
+			}
+
+			if w != "" && covered(requestExcludes, w) {
+				continue
+			}
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// ScopeCovers tells whether scope covers all of the requested scopes. An unrestricted scope covers everything; an
+// unrestricted request is only covered by an unrestricted scope.
+func ScopeCovers(scope, requested []string) bool {
+	if len(scope) == 0 {
+		return true
+	}
+
+	includes, excludes := splitScope(scope)
+	requestedIncludes, _ := splitScope(requested)
+
+	if len(requestedIncludes) == 0 {
+		return false
+	}
+
+	for _, r := range requestedIncludes {
+		if covered(excludes, r) {
+			return false
+		}
+
+		if len(includes) > 0 && !covered(includes, r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ValidateScope checks that all patterns are well-formed
+func ValidateScope(scope []string) error {
+	for _, s := range scope {
+		pattern := strings.TrimPrefix(s, "!")
+
+		if pattern == "" {
+			return fmt.Errorf("scope '%s' is empty", s)
+		}
+
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("scope '%s' is not a valid pattern: %w", s, err)
+		}
+	}
+
+	return nil
+}
+
+// UnmatchedScopes returns the exclusions of scope that exclude nothing, because none of the including patterns covers them
+func UnmatchedScopes(scope []string) []string {
+	includes, excludes := splitScope(scope)
+
+	if len(includes) == 0 {
+		return nil
+	}
+
+	var result []string
+
+	for _, x := range excludes {
+		matched := false
+
+		for _, i := range includes {
+			if covers(i, x) || covers(x, i) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			result = append(result, "!"+x)
+		}
+	}
+
+	return result
+}
+
+// covers tells whether pattern matches s or one of its ancestors
+func covers(pattern, s string) bool {
+	for i := 0; i <= len(s); i++ {
+		if i == len(s) || s[i] == '/' {
+			if matched, _ := path.Match(pattern, s[:i]); matched {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func covered(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if covers(p, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func splitScope(scope []string) (includes, excludes []string) {
+	for _, s := range scope {
+		if excluded, found := strings.CutPrefix(s, "!"); found {
+			excludes = append(excludes, excluded)
+		} else {
+			includes = append(includes, s)
+		}
+	}
+
+	return
+}
+
+// everythingIfEmpty returns the patterns, or a single empty pattern standing for everything
+func everythingIfEmpty(patterns []string) []string {
+	if len(patterns) == 0 {
+		return []string{""}
+	}
+
+	return patterns
+}
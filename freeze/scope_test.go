@@ -0,0 +1,92 @@
+package freeze_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/homeport/freeze-calendar-resource/freeze"
+)
+
+var _ = Describe("Scope", func() {
+	DescribeTable("overlap of window and request scopes",
+		func(window, request []string, expected bool) {
+			Expect(freeze.ScopesOverlap(window, request)).To(Equal(expected))
+		},
+		Entry("both unrestricted", nil, nil, true),
+		Entry("unrestricted window", nil, []string{"eu-de"}, true),
+		Entry("unrestricted request", []string{"eu-de"}, nil, true),
+		Entry("same scope", []string{"eu-de"}, []string{"eu-de"}, true),
+		Entry("different scope", []string{"eu-de"}, []string{"us-east"}, false),
+		Entry("one of many", []string{"us-east", "eu-de"}, []string{"ap-southeast", "eu-de"}, true),
+		Entry("prefix glob in window", []string{"eu-*"}, []string{"eu-de"}, true),
+		Entry("suffix glob in window", []string{"*-prod"}, []string{"eu-de"}, false),
+		Entry("glob in request", []string{"shop-prod"}, []string{"*-prod"}, true),
+		Entry("parent in window", []string{"eu"}, []string{"eu/de/frankfurt"}, true),
+		Entry("child in window", []string{"eu/de/frankfurt"}, []string{"eu"}, true),
+		Entry("sibling", []string{"eu/de"}, []string{"eu/nl"}, false),
+		Entry("no partial segment", []string{"eu"}, []string{"eu-de"}, false),
+		Entry("glob on a segment", []string{"eu/*/frankfurt"}, []string{"eu/de/frankfurt"}, true),
+		Entry("glob covering a parent", []string{"eu-*"}, []string{"eu-de/frankfurt"}, true),
+		Entry("excluded by window", []string{"eu-*", "!eu-de-sandbox"}, []string{"eu-de-sandbox"}, false),
+		Entry("not excluded by window", []string{"eu-*", "!eu-de-sandbox"}, []string{"eu-de"}, true),
+		Entry("only exclusions in window", []string{"!eu-de-sandbox"}, []string{"us-east"}, true),
+		Entry("child excluded by window", []string{"eu", "!eu/de"}, []string{"eu/de/frankfurt"}, false),
+		Entry("excluded by request", []string{"eu-de-sandbox"}, []string{"eu-*", "!eu-de-sandbox"}, false),
+		Entry("not excluded by request", []string{"eu-de"}, []string{"eu-*", "!eu-de-sandbox"}, true),
+		Entry("only exclusions in request", []string{"eu-de-sandbox"}, []string{"!eu-de-sandbox"}, false),
+		Entry("unrestricted window, request with exclusions", nil, []string{"!eu-de-sandbox"}, true),
+	)
+
+	DescribeTable("coverage of requested scopes",
+		func(scope, requested []string, expected bool) {
+			Expect(freeze.ScopeCovers(scope, requested)).To(Equal(expected))
+		},
+		Entry("unrestricted scope", nil, []string{"eu-de"}, true),
+		Entry("unrestricted request", []string{"eu-de"}, nil, false),
+		Entry("all requested scopes", []string{"eu-*"}, []string{"eu-de", "eu-nl"}, true),
+		Entry("only some requested scopes", []string{"eu-*"}, []string{"eu-de", "us-east"}, false),
+		Entry("child scope", []string{"eu"}, []string{"eu/de"}, true),
+		Entry("parent scope", []string{"eu/de"}, []string{"eu"}, false),
+		Entry("excluded scope", []string{"eu-*", "!eu-de-sandbox"}, []string{"eu-de-sandbox"}, false),
+	)
+
+	DescribeTable("validation",
+		func(scope []string, expected string) {
+			err := freeze.ValidateScope(scope)
+
+			if expected == "" {
+				Expect(err).ToNot(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(ContainSubstring(expected)))
+			}
+		},
+		Entry("valid patterns", []string{"eu-*", "eu/de", "!eu-de-sandbox"}, ""),
+		Entry("malformed pattern", []string{"eu-[de"}, "not a valid pattern"),
+		Entry("lone negation", []string{"!"}, "is empty"),
+	)
+
+	DescribeTable("exclusions matching nothing",
+		func(scope []string, expected []string) {
+			Expect(freeze.UnmatchedScopes(scope)).To(Equal(expected))
+		},
+		Entry("no exclusions", []string{"eu-*"}, nil),
+		Entry("only exclusions", []string{"!eu-de"}, nil),
+		Entry("matching exclusion", []string{"eu-*", "!eu-de-sandbox"}, nil),
+		Entry("exclusion of a child", []string{"eu", "!eu/de"}, nil),
+		Entry("exclusion matching nothing", []string{"eu-*", "!us-east"}, []string{"!us-east"}),
+	)
+
+	It("rejects a calendar with a malformed scope", func() {
+		_, err := freeze.LoadCalendar(strings.NewReader(`
+freeze_calendar:
+  - name: Broken
+    starts_at: 2023-08-11T06:00:00Z
+    ends_at: 2023-08-12T06:00:00Z
+    scope:
+      - "eu-[de"
+`))
+		Expect(err).To(MatchError(ContainSubstring("invalid scope of window 'Broken'")))
+	})
+})
@@ -0,0 +1,119 @@
+package freeze
+
+import (
+	"slices"
+	"time"
+)
+
+// State tells where the occurrence of a window is relative to the time it was evaluated at
+type State string
+
+const (
+	Active   = State("active")
+	Upcoming = State("upcoming")
+	Past     = State("past")
+)
+
+// Status is the occurrence of a window that is relevant at evaluation time: the active one, or else the next one, or
+// else the last one.
+type Status struct {
+	Window  // the occurrence
+	State   State
+	InScope bool // whether the window's scope overlaps the one evaluated for
+}
+
+// Statuses are the statuses of the windows of a calendar, in the order of the windows
+type Statuses []Status
+
+// Evaluate determines the status of each window of the calendar at t. Like the freeze itself, a window is active while
+// t plus the runway lies within it; windows without any occurrence are left out.
+func (c Calendar) Evaluate(t time.Time, runway time.Duration, scope []string) Statuses {
+	result := Statuses{}
+	tWithRunway := t.Add(runway)
+
+	for _, window := range c.Windows {
+		var relevant *Status
+
+		for occurrence := range window.Occurrences() {
+			relevant = &Status{
+				Window:  occurrence,
+				State:   Past,
+				InScope: ScopesOverlap(occurrence.Scope, scope),
+			}
+
+			if occurrence.End.Before(tWithRunway) {
+				continue
+			}
+
+			if occurrence.Start.After(tWithRunway) {
+				relevant.State = Upcoming
+			} else {
+				relevant.State = Active
+			}
+
+			break
+		}
+
+		if relevant != nil {
+			result = append(result, *relevant)
+		}
+	}
+
+	return result
+}
+
+// Frozen returns the active occurrences within scope, i.e. the windows that freeze
+func (s Statuses) Frozen() []Window {
+	var result []Window
+
+	for _, status := range s {
+		if status.State == Active && status.InScope {
+			result = append(result, status.Window)
+		}
+	}
+
+	return result
+}
+
+// Next returns the upcoming occurrence within scope that starts first
+func (s Statuses) Next() (Window, bool) {
+	var next *Window
+
+	for i, status := range s {
+		if status.State == Upcoming && status.InScope && (next == nil || status.Start.Before(next.Start)) {
+			next = &s[i].Window
+		}
+	}
+
+	if next == nil {
+		return Window{}, false
+	}
+
+	return *next, true
+}
+
+// Between returns the occurrences of all windows within scope that have not ended at from and start before until,
+// ordered by their start.
+func (c Calendar) Between(from, until time.Time, scope []string) []Window {
+	var result []Window
+
+	for _, window := range c.Windows {
+		if !ScopesOverlap(window.Scope, scope) {
+			continue
+		}
+
+		for occurrence := range window.Occurrences() {
+			if !occurrence.Start.Before(until) {
+				break
+			}
+
+			if occurrence.End.After(from) {
+				result = append(result, occurrence)
+			}
+		}
+	}
+
+	slices.SortStableFunc(result, func(a, b Window) int { return a.Start.Compare(b.Start) })
+
+	return result
+}
@@ -0,0 +1,107 @@
+package freeze_test
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/homeport/freeze-calendar-resource/freeze"
+)
+
+var _ = Describe("Status", func() {
+	var calendar *freeze.Calendar
+
+	names := func(windows []freeze.Window) []string {
+		var result []string
+
+		for _, w := range windows {
+			result = append(result, w.Name)
+		}
+
+		return result
+	}
+
+	BeforeEach(func() {
+		var err error
+		calendar, err = freeze.LoadCalendar(strings.NewReader(`
+freeze_calendar:
+  - name: Easter
+    starts_at: 2023-04-07T00:00:00Z
+    ends_at: 2023-04-11T00:00:00Z
+  - name: Oktoberfest
+    starts_at: 2023-10-11T16:00:00Z
+    ends_at: 2023-10-12T00:00:00Z
+    scope: [eu-de]
+  - name: Columbus Day
+    starts_at: 2023-10-09T00:00:00Z
+    ends_at: 2023-10-10T00:00:00Z
+    scope: [us]
+  - name: Patch Tuesday
+    starts_at: 2023-01-10T12:00:00Z
+    duration: 6h
+    recurrence: FREQ=MONTHLY;BYDAY=2TU
+`))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	Describe("evaluated during a window", func() {
+		var statuses freeze.Statuses
+
+		BeforeEach(func() {
+			statuses = calendar.Evaluate(time.Date(2023, time.October, 11, 17, 0, 0, 0, time.UTC), 0, []string{"eu-de"})
+		})
+
+		It("has the relevant occurrence of each window", func() {
+			Expect(statuses).To(HaveLen(4))
+			Expect(statuses[0].State).To(Equal(freeze.Past))
+			Expect(statuses[1].State).To(Equal(freeze.Active))
+			Expect(statuses[2].State).To(Equal(freeze.Past))
+			Expect(statuses[2].InScope).To(BeFalse())
+			Expect(statuses[3].State).To(Equal(freeze.Upcoming))
+			Expect(statuses[3].Start).To(Equal(time.Date(2023, time.November, 14, 12, 0, 0, 0, time.UTC)))
+		})
+
+		It("is frozen by the active window", func() {
+			Expect(names(statuses.Frozen())).To(Equal([]string{"Oktoberfest"}))
+		})
+
+		It("knows the next window", func() {
+			next, found := statuses.Next()
+			Expect(found).To(BeTrue())
+			Expect(next.Name).To(Equal("Patch Tuesday"))
+		})
+	})
+
+	Context("evaluated before a window with a runway reaching into it", func() {
+		It("is frozen", func() {
+			statuses := calendar.Evaluate(time.Date(2023, time.October, 11, 15, 0, 0, 0, time.UTC), 2*time.Hour, nil)
+			Expect(names(statuses.Frozen())).To(Equal([]string{"Oktoberfest"}))
+		})
+	})
+
+	Context("evaluated for another scope", func() {
+		It("is not frozen", func() {
+			statuses := calendar.Evaluate(time.Date(2023, time.October, 11, 17, 0, 0, 0, time.UTC), 0, []string{"us"})
+			Expect(statuses.Frozen()).To(BeEmpty())
+		})
+	})
+
+	Describe("Between", func() {
+		It("lists the occurrences within the range by their start", func() {
+			windows := calendar.Between(time.Date(2023, time.October, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, time.December, 1, 0, 0, 0, 0, time.UTC), nil)
+			Expect(names(windows)).To(Equal([]string{"Columbus Day", "Patch Tuesday", "Oktoberfest", "Patch Tuesday"}))
+		})
+
+		It("includes the ones that have started but not ended", func() {
+			windows := calendar.Between(time.Date(2023, time.October, 11, 20, 0, 0, 0, time.UTC), time.Date(2023, time.October, 12, 0, 0, 0, 0, time.UTC), nil)
+			Expect(names(windows)).To(Equal([]string{"Oktoberfest"}))
+		})
+
+		It("lists only those within scope", func() {
+			windows := calendar.Between(time.Date(2023, time.October, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, time.November, 1, 0, 0, 0, 0, time.UTC), []string{"us"})
+			Expect(names(windows)).To(Equal([]string{"Columbus Day", "Patch Tuesday"}))
+		})
+	})
+})
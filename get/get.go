@@ -5,14 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand/v2"
+	"os"
 	"slices"
 	"strings"
 	"time"
 
 	timeMachine "github.com/benbjohnson/clock"
-	"github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-billy/v5/osfs"
 	"github.com/go-playground/validator/v10"
 	"github.com/homeport/freeze-calendar-resource/freeze"
 	"github.com/homeport/freeze-calendar-resource/lgr"
@@ -33,11 +33,14 @@ type Response struct {
 type ContextKey string
 
 const ContextKeyClock = ContextKey("clock")
-const minimumRetryInterval = 10 * time.Second
+
+// MinimumRetryInterval is the shortest time gate mode waits between two attempts, so that the git server is not
+// polled excessively. A shorter retry_interval is raised to it (and a message is logged).
+const MinimumRetryInterval = 10 * time.Second
 
 func Get(ctx context.Context, req io.Reader, resp, w io.Writer, destination string) error {
 	var request Request
-	err := json.NewDecoder(req).Decode(&request)
+	err := resource.DecodeRequest(req, &request)
 
 	if err != nil {
 		return fmt.Errorf("unable to decode request: %w", err)
@@ -49,249 +52,342 @@ func Get(ctx context.Context, req io.Reader, resp, w io.Writer, destination stri
 		return fmt.Errorf("unable to build validator: %w", err)
 	}
 
-	auth, err := request.Source.Auth()
-
-	if err != nil {
-		return fmt.Errorf("unable to build authenticator: %w", err)
-	}
-
 	logLevel := lgr.InfoLevel
 
 	if request.Params.Verbose {
 		logLevel = lgr.DebugLevel
 	}
 
+	var clock timeMachine.Clock = timeMachine.New()
+
+	if value := ctx.Value(ContextKeyClock); value != nil {
+		clock = value.(timeMachine.Clock)
+	}
+
 	logger := lgr.Logger{
 		Level:  logLevel,
 		Writer: w,
+		Format: lgr.FormatOf(ctx, request.Params.LogFormat),
+		Now:    clock.Now,
 	}
 
-	var branch = request.Source.Branch
-
-	if branch == "" {
-		branch = "main"
-		logger.Debug("No branch given; falling back to %s", branch)
+	if request.Source.URL == "" && request.Source.Branch == "" {
+		logger.Debug("No branch given; falling back to main")
 	}
 
-	repo, err := git.PlainCloneContext(ctx, destination, false, &git.CloneOptions{
-		URL:           request.Source.URI,
-		ReferenceName: plumbing.ReferenceName(branch),
-		SingleBranch:  true,
-		Auth:          auth,
-		Progress:      logger,
-	})
+	fetcher, err := request.Source.Fetcher(logger)
 
 	if err != nil {
-		return fmt.Errorf("unable to clone: %w", err)
+		return err
 	}
 
-	worktree, err := repo.Worktree()
+	// Only in fuse and warn mode we want the specific version that was discovered by check.
+	// In gate mode we want the _latest_ version.
+	var pinned resource.Version
 
-	if err != nil {
-		return fmt.Errorf("unable to get worktree: %w", err)
+	if request.Params.Mode == resource.Fuse || request.Params.Mode == resource.Warn {
+		pinned = request.Version
 	}
 
-	head, err := repo.Head()
+	version, err := fetcher.Fetch(ctx, destination, pinned)
 
 	if err != nil {
-		return fmt.Errorf("unable to determine head: %w", err)
+		return err
 	}
 
-	// Only in fuse mode we want the specific SHA that was discovered by check.
-	// In gate mode we want to check out the _latest_ version of the branch,
-	// which has already been provided by the initial clone.
-	if request.Params.Mode == resource.Fuse {
-		err = worktree.Checkout(&git.CheckoutOptions{
-			Hash: plumbing.NewHash(request.Version.SHA),
-		})
+	if pinned != (resource.Version{}) && !version.Same(pinned) {
+		logger.Info("The calendar has changed since version %s was discovered; using %s instead, as %s keeps no history", pinned, version, request.Source.URL)
+	}
 
-		if err != nil {
-			return fmt.Errorf("unable to checkout %s: %w", request.Version.SHA, err)
-		}
+	build := freeze.Build{
+		Team:     os.Getenv("BUILD_TEAM_NAME"),
+		Pipeline: os.Getenv("BUILD_PIPELINE_NAME"),
+		Job:      os.Getenv("BUILD_JOB_NAME"),
 	}
 
 	var totalNumberOfFreezeWindows int
 	var numberOfActiveFreezeWindows int
+	var lastActiveFreezeWindows []freeze.Window
+	var override *freeze.Override
+	var status Status
+	var ignoredFreezeWindows []freeze.Window
+	var waitingSince time.Time
+	var fetchErrors int
+	policy := DefaultRetryPolicy
+
+	if request.Params.MaxFetchErrors > 0 {
+		policy.MaxConsecutiveErrors = request.Params.MaxFetchErrors
+	}
 
-	logger.Info("Using freeze calendar from %s at %s", request.Source.Path, head.Hash())
-	var windowsPrinted []*plumbing.Reference
+	retryInterval := request.Params.RetryInterval.Duration
 
-	for {
-		calendarFile, err := worktree.Filesystem.Open(request.Source.Path)
+	if request.Source.RetryInterval.Duration != 0 {
+		logger.Info("WARNING: retry_interval in the source is deprecated and will be rejected by the next release; set it in the params of the get step instead")
 
-		if err != nil {
-			return fmt.Errorf("unable to read calendar file from path %s: %w", request.Source.Path, err)
+		if retryInterval == 0 {
+			retryInterval = request.Source.RetryInterval.Duration
 		}
+	}
 
-		calendar, err := freeze.LoadCalendar(calendarFile)
-
-		if err != nil {
-			return fmt.Errorf("unable to load calendar: %w", err)
+	if request.Params.Mode == resource.Gate && retryInterval < MinimumRetryInterval {
+		if retryInterval != 0 {
+			logger.Info("Retry interval %s is below the minimum of %s; using the minimum instead", retryInterval, MinimumRetryInterval)
 		}
 
-		var now time.Time
+		retryInterval = MinimumRetryInterval
+	}
 
-		if value := ctx.Value(ContextKeyClock); value != nil {
-			now = value.(timeMachine.Clock).Now().UTC()
-		} else {
-			now = time.Now().UTC()
-		}
+	// Included repositories are cloned once per step, so that polling in gate mode does not clone them again and again.
+	// Included calendars therefore stay at the version fetched first, and only a change of the source's calendars is noticed.
+	includes := resource.NewGitFetcher(ctx, request.Source)
 
-		nowWithRunway := now.Add(request.Params.Runway.Duration)
+	var gaveUp string
 
-		var activeFreezeWindows []freeze.Window
+	logger.Info("Using freeze calendar from %s at %s", strings.Join(request.Source.CalendarPaths(), ", "), version)
+	var windowsPrinted []resource.Version
+	var attempt int
 
-		for _, window := range calendar.Windows {
-			if window.Start.After(nowWithRunway) {
-				logger.Debug("Skipping window '%s' as its start %s is in the future (after %s + %s runway)", window.Name, window.Start.UTC(), now.UTC(), request.Params.Runway.Duration)
-				continue
-			}
+	// loops until no window is active, an override applies, warn mode has ignored the active windows, or the gate gave up
+	for done := false; !done; {
+		calendar, err := request.Source.LoadCalendar(osfs.New(destination), includes)
 
-			if window.End.Before(nowWithRunway) {
-				logger.Debug("Skipping window '%s' as its end %s is in the past (before %s + %s runway)", window.Name, window.End.UTC(), now.UTC(), request.Params.Runway.Duration)
-				continue
-			}
+		if err != nil {
+			return fmt.Errorf("unable to load calendar: %w", err)
+		}
 
-			// Now we know we are within a freeze window.
-			// Let's check if the scope matches. No scope for a window or the request means all windows are considered matching, as long as the dates match.
-			if len(request.Params.Scope) == 0 {
-				activeFreezeWindows = append(activeFreezeWindows, window)
-			} else {
-				for _, rs := range request.Params.Scope {
-					if len(window.Scope) == 0 {
-						logger.Debug("Adding window '%s' as it is not restricted to any scopes", window)
-						activeFreezeWindows = append(activeFreezeWindows, window)
-					} else {
-						for _, ws := range window.Scope {
-							if rs == ws {
-								activeFreezeWindows = append(activeFreezeWindows, window)
-							} else {
-								logger.Debug("Skipping window '%s' as its scope %s does not match the configured scope %s", window, ws, rs)
-							}
-						}
-					}
-				}
+		now := clock.Now().UTC()
+		statuses := calendar.Evaluate(now, request.Params.Runway.Duration, request.Params.Scope)
+		status = newStatus(statuses, now, request.Params.Runway.Duration, request.Params.Scope)
+
+		for _, s := range statuses {
+			switch {
+			case s.State == freeze.Upcoming:
+				logger.Debug("Skipping window '%s' as its start %s is in the future (after %s + %s runway)", s.Name, s.Start.UTC(), now, request.Params.Runway.Duration)
+			case s.State == freeze.Past:
+				logger.Debug("Skipping window '%s' as its end %s is in the past (before %s + %s runway)", s.Name, s.End.UTC(), now, request.Params.Runway.Duration)
+			case !s.InScope:
+				// No scope for a window or the request means all windows are considered matching, as long as the dates match.
+				logger.Debug("Skipping window '%s' as its scope %s does not match the configured scope %s", s.Window, strings.Join(s.Scope, ", "), strings.Join(request.Params.Scope, ", "))
 			}
 		}
 
+		activeFreezeWindows := statuses.Frozen()
+
 		totalNumberOfFreezeWindows = len(calendar.Windows)
 		numberOfActiveFreezeWindows = len(activeFreezeWindows)
+		lastActiveFreezeWindows = activeFreezeWindows
+
+		fields := lgr.Fields{
+			Windows: mapFunc(activeFreezeWindows, func(w freeze.Window) string { return w.Name }),
+			Scope:   request.Params.Scope,
+			Head:    version.String(),
+			Runway:  request.Params.Runway.Duration,
+			Attempt: attempt,
+		}
 
 		if len(activeFreezeWindows) == 0 {
-			logger.Info("No active freeze windows")
+			logger.Event("freeze-inactive", fields).Info("No active freeze windows")
+			break
+		} else if o, found := calendar.Override(now, request.Params.Scope, build); found {
+			logger.Event("override-applied", fields).Info("%d freeze windows are currently active for the configured scope %s, but there is an override %s:\n%s",
+				len(activeFreezeWindows),
+				strings.Join(request.Params.Scope, ", "),
+				o,
+				strings.Join(mapFunc(activeFreezeWindows, func(w freeze.Window) string { return w.String() }), "\n"),
+			)
+
+			override = &o
 			break
 		} else {
 			switch request.Params.Mode {
 			default:
 				return fmt.Errorf("unknown mode %s", request.Params.Mode)
 			case resource.Fuse:
+				logger.Event("fuse-blown", fields).Error("Fuse has blown at %s", version)
+
 				return fmt.Errorf(
 					"fuse has blown because the following freeze windows are currently active for the configured scope %s:\n%s",
 					strings.Join(request.Params.Scope, ", "),
 					strings.Join(mapFunc(activeFreezeWindows, func(w freeze.Window) string { return w.String() }), "\n"),
 				)
+			case resource.Warn:
+				logger.Event("freeze-ignored", fields).Error(
+					"WARNING: Deploying anyway, although the following freeze windows are currently active for the configured scope %s:\n%s",
+					strings.Join(request.Params.Scope, ", "),
+					strings.Join(mapFunc(activeFreezeWindows, func(w freeze.Window) string { return w.String() }), "\n"),
+				)
+
+				ignoredFreezeWindows = activeFreezeWindows
+				done = true
 			case resource.Gate:
-				if !slices.Contains(windowsPrinted, head) {
-					logger.Info("At %s, %d freeze windows are currently active for the configured scope %s: %s",
-						head.Hash(),
+				if waitingSince.IsZero() {
+					waitingSince = now
+				}
+
+				// Unless the calendar changes, the gate opens once the last of the active windows has ended.
+				lift := slices.MaxFunc(activeFreezeWindows, func(a, b freeze.Window) int { return a.End.Compare(b.End) }).End.Add(-request.Params.Runway.Duration)
+				deadline := waitingSince.Add(request.Params.MaxWait.Duration)
+
+				if request.Params.MaxWait.Duration > 0 && !now.Before(deadline) {
+					gaveUp = fmt.Sprintf("gave up waiting after %s; the freeze would have lasted another %s (until %s)", now.Sub(waitingSince), lift.Sub(now).Round(time.Second), lift.UTC())
+
+					if request.Params.OnTimeout != resource.Pass {
+						logger.Event("gate-timeout", fields).Error("Gate %s", gaveUp)
+						return fmt.Errorf("gate %s", gaveUp)
+					}
+
+					logger.Event("gate-timeout", fields).Error("WARNING: Passing the gate, although it %s", gaveUp)
+					done = true
+					break
+				}
+
+				if !slices.Contains(windowsPrinted, version) {
+					logger.Event("gate-waiting", fields).Info("At %s, %d freeze windows are currently active for the configured scope %s: %s",
+						version,
 						len(activeFreezeWindows),
 						strings.Join(request.Params.Scope, ", "),
 						strings.Join(mapFunc(activeFreezeWindows, func(w freeze.Window) string { return w.String() }), "\n"),
 					)
 
-					windowsPrinted = append(windowsPrinted, head)
+					windowsPrinted = append(windowsPrinted, version)
 				}
 
-				newHead, err := pullAndReset(ctx, repo, branch, auth, logger)
+				// Sleep until the active windows have ended or the deadline is reached, whichever comes first. Meanwhile, poll
+				// for calendar changes every interval; only a new head brings the calendar to be evaluated again early.
+				// A window still covers its end, hence the second on top.
+				wake := lift.Add(time.Second)
 
-				if err != nil {
-					return err
+				if request.Params.MaxWait.Duration > 0 && deadline.Before(wake) {
+					wake = deadline
 				}
 
-				select {
-				case <-ctx.Done():
-					return ctx.Err()
-				default:
-					if newHead.Hash() != head.Hash() {
-						logger.Info("Head has moved from %s to %s", head.Hash(), newHead.Hash())
-						head = newHead
+			poll:
+				for now := clock.Now().UTC(); now.Before(wake); now = clock.Now().UTC() {
+					latest, err := fetcher.Update(ctx)
+
+					if err != nil {
+						if ctx.Err() != nil {
+							return ctx.Err()
+						}
+
+						fetchErrors++
+
+						if fetchErrors >= policy.MaxConsecutiveErrors {
+							return fmt.Errorf("giving up after %d consecutive failed attempts to fetch the calendar: %w", fetchErrors, err)
+						}
+
+						backoff := min(policy.Backoff(fetchErrors, rand.Float64()), wake.Sub(now))
+
+						logger.Event("fetch-failed", lgr.Fields{Head: version.String(), Attempt: fetchErrors}).Error("Attempt %d of %d to fetch the calendar failed; retrying in %s: %s", fetchErrors, policy.MaxConsecutiveErrors, backoff, err)
+
+						err = sleep(ctx, clock, backoff)
+
+						if err != nil {
+							return err
+						}
+
+						continue
+					}
+
+					fetchErrors = 0
+
+					if latest != version {
+						logger.Event("head-moved", lgr.Fields{Head: latest.String()}).Info("Head has moved from %s to %s", version, latest)
+						version = latest
+						break poll
+					}
+
+					interval := min(policy.Interval(retryInterval, rand.Float64()), wake.Sub(now))
+					attempt++
+
+					// In text format, progress is a dot per attempt; structured formats get a record instead.
+					if logger.Structured() {
+						fields.Attempt = attempt
+						logger.Event("gate-waiting", fields).Info("Freeze windows are active until %s; checking for calendar changes in %s", lift.UTC(), interval)
 					} else {
 						logger.Write([]byte("."))
+					}
 
-						if request.Params.RetryInterval.Duration < minimumRetryInterval {
-							time.Sleep(minimumRetryInterval)
-						} else {
-							time.Sleep(request.Params.RetryInterval.Duration)
-						}
+					err = sleep(ctx, clock, interval)
+
+					if err != nil {
+						return err
 					}
 				}
 			}
 		}
 	}
 
-	// re-read the _actual_ head, regardless of any branch switches made before
-	head, err = repo.Head()
+	status.Version = version
+	err = writeStatus(destination, status)
 
 	if err != nil {
-		return fmt.Errorf("unable to determine actual HEAD: %w", err)
+		return err
+	}
+
+	if len(ignoredFreezeWindows) > 0 {
+		err = writeWarning(destination, ignoredFreezeWindows)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	// keep the boundary of a time-driven version if we are still at its calendar
+	if version.Same(request.Version) {
+		version.Boundary = request.Version.Boundary
 	}
 
 	response := Response{
-		Version: resource.Version{SHA: head.Hash().String()},
+		Version: version,
 		Metadata: []resource.NameValuePair{
 			{Name: "total number of freeze windows", Value: fmt.Sprintf("%d", totalNumberOfFreezeWindows)},
 			{Name: "number of active freeze windows", Value: fmt.Sprintf("%d", numberOfActiveFreezeWindows)},
 		},
 	}
 
-	return json.NewEncoder(resp).Encode(response)
-}
-
-// https://stackoverflow.com/a/71624929
-func mapFunc[T, U any](ts []T, f func(T) U) []U {
-	us := make([]U, len(ts))
-
-	for i := range ts {
-		us[i] = f(ts[i])
+	if len(lastActiveFreezeWindows) > 0 {
+		response.Metadata = append(response.Metadata, resource.NameValuePair{Name: "active freeze windows", Value: strings.Join(mapFunc(lastActiveFreezeWindows, withOrigin), ", ")})
 	}
 
-	return us
-}
-
-func pullAndReset(ctx context.Context, repo *git.Repository, branch string, auth transport.AuthMethod, logger lgr.Logger) (*plumbing.Reference, error) {
-	err := repo.FetchContext(ctx, &git.FetchOptions{
-		Auth:     auth,
-		Progress: logger,
-	})
-
-	if err != nil {
-		if err == git.NoErrAlreadyUpToDate {
-			return repo.Head() // already at the latest commit
-		} else {
-			return nil, fmt.Errorf("fetch failed: %w", err)
-		}
+	if override != nil {
+		response.Metadata = append(response.Metadata,
+			resource.NameValuePair{Name: "override approved by", Value: override.Approver},
+			resource.NameValuePair{Name: "override reason", Value: override.Reason},
+			resource.NameValuePair{Name: "override expires at", Value: override.ExpiresAt.String()},
+		)
 	}
 
-	remoteHead, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", branch), true)
+	if gaveUp != "" {
+		response.Metadata = append(response.Metadata, resource.NameValuePair{Name: "gate timed out", Value: gaveUp})
+	}
 
-	if err != nil {
-		return nil, fmt.Errorf("unable to resolve reference to branch %s: %w", branch, err)
+	if len(ignoredFreezeWindows) > 0 {
+		response.Metadata = append(response.Metadata,
+			resource.NameValuePair{Name: "warning", Value: "deployed during an active freeze"},
+			resource.NameValuePair{Name: "ignored freeze windows", Value: strings.Join(mapFunc(ignoredFreezeWindows, withOrigin), ", ")},
+		)
 	}
 
-	worktree, err := repo.Worktree()
+	return json.NewEncoder(resp).Encode(response)
+}
 
-	if err != nil {
-		return nil, err
+// withOrigin describes a window by its name and the file it came from
+func withOrigin(w freeze.Window) string {
+	if w.Origin == "" {
+		return w.Name
 	}
 
-	err = worktree.Reset(&git.ResetOptions{
-		Commit: remoteHead.Hash(),
-		Mode:   git.HardReset,
-	})
+	return fmt.Sprintf("%s (%s)", w.Name, w.Origin)
+}
 
-	if err != nil {
-		return nil, fmt.Errorf("resetting the workspace failed: %w", err)
+// https://stackoverflow.com/a/71624929
+func mapFunc[T, U any](ts []T, f func(T) U) []U {
+	us := make([]U, len(ts))
+
+	for i := range ts {
+		us[i] = f(ts[i])
 	}
 
-	return repo.Head()
+	return us
 }
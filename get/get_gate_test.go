@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
 	"path"
 	"strings"
 	"time"
@@ -16,21 +17,25 @@ import (
 	"github.com/homeport/freeze-calendar-resource/get"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
 )
 
 var _ = Describe("Get in gate mode", func() {
+	const timeout = 3 * time.Second // in a pipeline, this is taken care of by a timeout parameter on the step
+
 	var (
 		err            error
 		req            io.Reader
 		resp           strings.Builder
-		log            strings.Builder
+		log            *gbytes.Buffer
 		repo           *git.Repository
 		origin         string
 		initialHead    plumbing.Hash
 		destinationDir string
 		clock          *timeMachine.Mock
 		now            time.Time
-		retryInterval  time.Duration
+		advanceClock   bool
+		elapsed        time.Duration
 	)
 
 	BeforeEach(func() {
@@ -38,10 +43,10 @@ var _ = Describe("Get in gate mode", func() {
 		origin = path.Join(tmpDir, "remote")
 		destinationDir = path.Join(tmpDir, "resource-destination-directory")
 		resp = strings.Builder{}
-		log = strings.Builder{}
+		log = gbytes.NewBuffer()
 		clock = timeMachine.NewMock()
-		now = time.Unix(1691780400, 0)   // 2023-08-11T19:00:00Z
-		retryInterval = 10 * time.Second // must not be below enforced minimum
+		now = time.Unix(1691780400, 0) // 2023-08-11T19:00:00Z
+		advanceClock = false
 
 		repo, err = git.PlainInitWithOptions(origin, &git.PlainInitOptions{
 			InitOptions: git.InitOptions{DefaultBranch: plumbing.Main},
@@ -60,32 +65,37 @@ freeze_calendar:
 
 	JustBeforeEach(func(sCtx SpecContext) {
 		clock.Set(now)
-		ctx := context.WithValue(sCtx, get.ContextKeyClock, clock)
-		ctx, cancel := context.WithTimeout(ctx, 5*retryInterval) // in a pipeline, this is taken care of by a timeout parameter on the step
+		ctx, cancel := context.WithTimeout(context.WithValue(sCtx, get.ContextKeyClock, clock), timeout)
 		defer cancel()
 
-		err = get.Get(ctx, req, &resp, &log, destinationDir)
+		finished := make(chan struct{})
+
+		if advanceClock {
+			go fireTimers(clock, finished)
+		}
+
+		started := time.Now()
+		err = get.Get(ctx, req, &resp, log, destinationDir)
+		elapsed = time.Since(started)
+		close(finished)
 	})
 
+	gateRequest := func(params string) io.Reader {
+		return strings.NewReader(fmt.Sprintf(`{
+			"source": { "uri": "%s", "path": "calendar.yaml" },
+			"version": { "sha": "%s" },
+			"params": { "mode": "gate", %s }
+		}`, origin, initialHead, params))
+	}
+
 	Context("gate mode", func() {
 		Context("request without scope", func() {
 			BeforeEach(func() {
-				req = strings.NewReader(fmt.Sprintf(`{
-					"source": {
-						"uri": "%s",
-						"path": "calendar.yaml"
-					},
-					"version": { "sha": "%s" },
-					"params": {
-						"mode": "gate",
-						"retry_interval": "%s",
-						"verbose": true
-					}
-				}`, origin, initialHead, retryInterval))
+				req = gateRequest(`"retry_interval": "10s", "verbose": true`)
 			})
 
 			It("fails", func() {
-				Eventually(err).Should(HaveOccurred())
+				Expect(err).Should(HaveOccurred())
 			})
 
 			// If neither the clock advances nor the calendar gets updated, we will fail eventually
@@ -93,21 +103,30 @@ freeze_calendar:
 				Expect(err).To(MatchError(ContainSubstring("context deadline exceeded")))
 			})
 
+			It("stops waiting as soon as the context is done", func() {
+				Expect(elapsed).To(BeNumerically("<", timeout+time.Second))
+			})
+
 			Context("time has gone beyond freeze window", func() {
 				BeforeEach(func() {
 					now = time.Unix(1692615900, 0) // 2023-08-21T11:05:00Z
 				})
 
 				It("succeeds", func() {
-					Eventually(err).ShouldNot(HaveOccurred())
+					Expect(err).ShouldNot(HaveOccurred())
 				})
 			})
 
 			Context("freeze window has been shortened", func() {
 				BeforeEach(func() {
-					go func() { // make sure we tried unsuccessfully
-						time.Sleep(3 * retryInterval)
-						_, err = addAndCommit(repo, "calendar.yaml", []byte(`
+					advanceClock = true
+
+					go func() {
+						defer GinkgoRecover()
+
+						// make sure we tried unsuccessfully
+						Eventually(log).Should(gbytes.Say("freeze windows are currently active"))
+						_, err := addAndCommit(repo, "calendar.yaml", []byte(`
 freeze_calendar:
   - name: Unit Test
     starts_at: 2023-07-20T09:00:00Z
@@ -118,13 +137,221 @@ freeze_calendar:
 				})
 
 				It("succeeds", func() {
-					Expect(err).ShouldNot(HaveOccurred(), fmt.Sprintf("repo: %s\nlog:\n%s", destinationDir, log.String()))
+					Expect(err).ShouldNot(HaveOccurred(), fmt.Sprintf("repo: %s\nlog:\n%s", destinationDir, log.Contents()))
+				})
+			})
+		})
+
+		Context("calendar including another one", func() {
+			var corporate string
+
+			BeforeEach(func() {
+				advanceClock = true
+				corporate = path.Join(GinkgoT().TempDir(), "corporate")
+
+				corporateRepo, err := git.PlainInitWithOptions(corporate, &git.PlainInitOptions{
+					InitOptions: git.InitOptions{DefaultBranch: plumbing.Main},
+				})
+				Expect(err).ShouldNot(HaveOccurred())
+
+				_, err = addAndCommit(corporateRepo, "corporate.yaml", []byte(`
+freeze_calendar:
+  - name: Corporate Holiday
+    starts_at: 2023-01-01T00:00:00Z
+    ends_at: 2023-01-02T00:00:00Z
+`), "Create corporate calendar")
+				Expect(err).ShouldNot(HaveOccurred())
+
+				initialHead, err = addAndCommit(repo, "calendar.yaml", []byte(fmt.Sprintf(`
+include:
+  - uri: %s
+    path: corporate.yaml
+freeze_calendar:
+  - name: Unit Test
+    starts_at: 2023-07-20T09:00:00Z
+    ends_at: 2023-08-20T11:00:00Z
+`, corporate)), "Include the corporate calendar")
+				Expect(err).ShouldNot(HaveOccurred())
+
+				req = gateRequest(`"retry_interval": "10s"`)
+
+				go func() {
+					defer GinkgoRecover()
+
+					Eventually(log).Should(gbytes.Say("freeze windows are currently active"))
+
+					// a re-evaluation that cloned the included repository again would fail now
+					Expect(os.RemoveAll(corporate)).To(Succeed())
+
+					_, err := addAndCommit(repo, "calendar.yaml", []byte(fmt.Sprintf(`
+include:
+  - uri: %s
+    path: corporate.yaml
+freeze_calendar:
+  - name: Unit Test
+    starts_at: 2023-07-20T09:00:00Z
+    ends_at: 2023-08-10T11:00:00Z
+`, corporate)), "Shorten freeze window")
+					Expect(err).ShouldNot(HaveOccurred())
+				}()
+			})
+
+			It("clones the included repository only once", func() {
+				Expect(err).ShouldNot(HaveOccurred(), string(log.Contents()))
+				Expect(resp.String()).To(ContainSubstring(`"total number of freeze windows","value":"2"`))
+			})
+		})
+
+		Context("calendar repository becoming unavailable", func() {
+			BeforeEach(func() {
+				advanceClock = true
+				req = gateRequest(`"retry_interval": "10s"`)
+
+				go func() {
+					defer GinkgoRecover()
+
+					Eventually(log).Should(gbytes.Say("freeze windows are currently active"))
+					Expect(os.RemoveAll(origin)).To(Succeed())
+				}()
+			})
+
+			It("backs off exponentially", func() {
+				// plus up to 10% jitter
+				Expect(log).To(gbytes.Say(`Attempt 1 of 5 to fetch the calendar failed; retrying in 10(\.\d+)?s`))
+				Expect(log).To(gbytes.Say(`Attempt 2 of 5 to fetch the calendar failed; retrying in 2[01](\.\d+)?s`))
+				Expect(log).To(gbytes.Say(`Attempt 4 of 5 to fetch the calendar failed; retrying in 1m2[0-7](\.\d+)?s`))
+			})
+
+			It("gives up after too many consecutive errors", func() {
+				Expect(err).To(MatchError(ContainSubstring("giving up after 5 consecutive failed attempts to fetch the calendar")))
+			})
+		})
+
+		Context("request with a retry interval below the minimum", func() {
+			BeforeEach(func() {
+				advanceClock = true
+				req = gateRequest(`"retry_interval": "1s", "max_wait": "30s", "on_timeout": "pass", "log_format": "json"`)
+			})
+
+			It("says so", func() {
+				Expect(log).To(gbytes.Say("Retry interval 1s is below the minimum of 10s; using the minimum instead"))
+			})
+
+			It("waits for the minimum interval between attempts", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(string(log.Contents())).To(ContainSubstring(`"attempt":3`))
+				Expect(string(log.Contents())).ToNot(ContainSubstring(`"attempt":4`))
+			})
+		})
+
+		Context("request with the deprecated retry interval in the source", func() {
+			BeforeEach(func() {
+				advanceClock = true
+				req = strings.NewReader(fmt.Sprintf(`{
+					"source": { "uri": "%s", "path": "calendar.yaml", "retry_interval": "1s" },
+					"version": { "sha": "%s" },
+					"params": { "mode": "gate", "max_wait": "30s", "on_timeout": "pass" }
+				}`, origin, initialHead))
+			})
+
+			It("warns about it", func() {
+				Expect(log).To(gbytes.Say("retry_interval in the source is deprecated"))
+			})
+
+			It("uses it", func() {
+				Expect(log).To(gbytes.Say("Retry interval 1s is below the minimum of 10s"))
+			})
+		})
+
+		Context("request with a maximum wait", func() {
+			BeforeEach(func() {
+				advanceClock = true
+			})
+
+			Context("failing on timeout", func() {
+				BeforeEach(func() {
+					req = gateRequest(`"max_wait": "10m", "on_timeout": "fail"`)
+				})
+
+				It("fails with how much longer the freeze would have lasted", func() {
+					Expect(err).To(MatchError(ContainSubstring("gate gave up waiting after 10m0s; the freeze would have lasted another 207h50m0s")))
+				})
+			})
+
+			Context("passing on timeout", func() {
+				BeforeEach(func() {
+					req = gateRequest(`"max_wait": "10m", "on_timeout": "pass"`)
+				})
+
+				It("succeeds", func() {
+					Expect(err).ShouldNot(HaveOccurred())
+				})
+
+				It("adds the timeout to the metadata", func() {
+					Expect(resp.String()).To(ContainSubstring(`"name":"gate timed out"`))
 				})
 			})
 		})
+
+		Context("freeze window ending before the next retry", func() {
+			BeforeEach(func() {
+				advanceClock = true
+				now = time.Date(2023, time.August, 20, 10, 59, 58, 0, time.UTC)
+				req = gateRequest(`"retry_interval": "1h"`)
+			})
+
+			It("sleeps until the freeze window ends", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(clock.Now()).To(BeTemporally("<", now.Add(time.Minute)))
+			})
+		})
+
+		Context("freeze window ending after a few retries", func() {
+			BeforeEach(func() {
+				advanceClock = true
+				now = time.Date(2023, time.August, 20, 10, 59, 25, 0, time.UTC)
+
+				initialHead, err = addAndCommit(repo, "calendar.yaml", []byte(`
+freeze_calendar:
+  - name: Unit Test
+    starts_at: 2023-07-20T09:00:00Z
+    ends_at: 2023-08-20T11:00:00Z
+  - name: Bygone
+    starts_at: 2023-01-01T00:00:00Z
+    ends_at: 2023-01-02T00:00:00Z
+`), "Add a past freeze window")
+				Expect(err).ShouldNot(HaveOccurred())
+
+				req = gateRequest(`"retry_interval": "10s", "log_format": "json", "verbose": true`)
+			})
+
+			It("checks for calendar changes until the freeze window ends", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(string(log.Contents())).To(ContainSubstring("Freeze windows are active until 2023-08-20 11:00:00 +0000 UTC; checking for calendar changes in"))
+				Expect(string(log.Contents())).To(ContainSubstring(`"attempt":4`))
+				Expect(clock.Now()).To(BeTemporally("==", time.Date(2023, time.August, 20, 11, 0, 1, 0, time.UTC)))
+			})
+
+			It("evaluates the calendar again only once the freeze window has ended", func() {
+				Expect(strings.Count(string(log.Contents()), "Skipping window 'Bygone'")).To(Equal(2))
+			})
+		})
 	})
 })
 
+// fireTimers lets time pass on the mock clock whenever someone waits for it, until finished is closed
+func fireTimers(clock *timeMachine.Mock, finished <-chan struct{}) {
+	for {
+		select {
+		case <-finished:
+			return
+		default:
+			clock.WaitForAllTimers()
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
 func addAndCommit(r *git.Repository, fileName string, content []byte, msg string) (plumbing.Hash, error) {
 	w, err := r.Worktree()
 
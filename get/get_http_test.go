@@ -0,0 +1,150 @@
+package get_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	timeMachine "github.com/benbjohnson/clock"
+
+	"github.com/homeport/freeze-calendar-resource/get"
+	"github.com/homeport/freeze-calendar-resource/resource"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Get from an HTTP source", func() {
+	const (
+		frozen = `
+freeze_calendar:
+  - name: Oktoberfest
+    starts_at: 2023-10-11T16:00:00Z
+    ends_at: 2023-10-12T00:00:00Z
+`
+		thawed = `
+freeze_calendar: []
+`
+	)
+
+	var (
+		err            error
+		server         *httptest.Server
+		mutex          sync.Mutex
+		calendar       string
+		etag           string
+		notModified    int
+		mode           string
+		resp           strings.Builder
+		destinationDir string
+	)
+
+	serve := func(c, e string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		calendar, etag = c, e
+	}
+
+	BeforeEach(func() {
+		destinationDir = path.Join(GinkgoT().TempDir(), "resource-destination-directory")
+		resp = strings.Builder{}
+		notModified = 0
+		serve(frozen, `"v1"`)
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if r.Header.Get("If-None-Match") == etag {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			w.Header().Set("ETag", etag)
+			_, _ = w.Write([]byte(calendar))
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	JustBeforeEach(func(sCtx SpecContext) {
+		clock := timeMachine.NewMock()
+		clock.Set(time.Date(2023, time.October, 11, 17, 0, 0, 0, time.UTC))
+
+		ctx, cancel := context.WithTimeout(context.WithValue(sCtx, get.ContextKeyClock, clock), 3*time.Second)
+		defer cancel()
+
+		finished := make(chan struct{})
+		defer close(finished)
+		go fireTimers(clock, finished)
+
+		req := strings.NewReader(fmt.Sprintf(`{
+			"source": { "url": "%s/cab/freeze-calendar.yaml" },
+			"version": { "etag": "\"v1\"" },
+			"params": { "mode": "%s" }
+		}`, server.URL, mode))
+
+		err = get.Get(ctx, req, &resp, GinkgoWriter, destinationDir)
+	})
+
+	Context("in fuse mode", func() {
+		BeforeEach(func() {
+			mode = "fuse"
+		})
+
+		It("blows the fuse", func() {
+			Expect(err).To(MatchError(ContainSubstring("fuse has blown")))
+		})
+
+		It("downloads the calendar", func() {
+			Expect(path.Join(destinationDir, "freeze-calendar.yaml")).To(BeAnExistingFile())
+		})
+
+		Context("without an active freeze window", func() {
+			BeforeEach(func() {
+				serve(thawed, `"v2"`)
+			})
+
+			It("succeeds with the version downloaded", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+
+				var response get.Response
+				Expect(json.Unmarshal([]byte(resp.String()), &response)).To(Succeed())
+				Expect(response.Version).To(Equal(resource.Version{ETag: `"v2"`}))
+			})
+
+			It("writes the calendar to the destination", func() {
+				Expect(os.ReadFile(path.Join(destinationDir, "freeze-calendar.yaml"))).To(BeEquivalentTo(thawed))
+			})
+		})
+	})
+
+	Context("in gate mode", func() {
+		BeforeEach(func() {
+			mode = "gate"
+
+			go func() {
+				defer GinkgoRecover()
+
+				Eventually(func() int {
+					mutex.Lock()
+					defer mutex.Unlock()
+					return notModified
+				}).Should(BeNumerically(">=", 2))
+
+				serve(thawed, `"v2"`)
+			}()
+		})
+
+		It("polls with If-None-Match until the calendar changes", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(resp.String()).To(ContainSubstring(`"etag":"\"v2\""`))
+		})
+	})
+})
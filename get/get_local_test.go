@@ -0,0 +1,109 @@
+package get_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	timeMachine "github.com/benbjohnson/clock"
+
+	"github.com/homeport/freeze-calendar-resource/get"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Get from a local file", func() {
+	var (
+		err            error
+		calendar       string
+		mode           string
+		resp           strings.Builder
+		log            *gbytes.Buffer
+		destinationDir string
+	)
+
+	BeforeEach(func() {
+		tmpDir := GinkgoT().TempDir()
+		calendar = path.Join(tmpDir, "volume", "freeze-calendar.yaml")
+		destinationDir = path.Join(tmpDir, "resource-destination-directory")
+		resp = strings.Builder{}
+		log = gbytes.NewBuffer()
+
+		Expect(os.MkdirAll(path.Dir(calendar), 0o755)).To(Succeed())
+		Expect(os.WriteFile(calendar, []byte(`
+freeze_calendar:
+  - name: Oktoberfest
+    starts_at: 2023-10-11T16:00:00Z
+    ends_at: 2023-10-12T00:00:00Z
+`), 0o644)).To(Succeed())
+	})
+
+	JustBeforeEach(func(sCtx SpecContext) {
+		clock := timeMachine.NewMock()
+		clock.Set(time.Date(2023, time.October, 11, 17, 0, 0, 0, time.UTC))
+
+		ctx, cancel := context.WithTimeout(context.WithValue(sCtx, get.ContextKeyClock, clock), 3*time.Second)
+		defer cancel()
+
+		finished := make(chan struct{})
+		defer close(finished)
+		go fireTimers(clock, finished)
+
+		req := strings.NewReader(fmt.Sprintf(`{
+			"source": { "url": "file://%s" },
+			"version": { "hash": "sha256:0" },
+			"params": { "mode": "%s" }
+		}`, calendar, mode))
+
+		err = get.Get(ctx, req, &resp, log, destinationDir)
+	})
+
+	Context("in fuse mode", func() {
+		BeforeEach(func() {
+			mode = "fuse"
+		})
+
+		It("blows the fuse", func() {
+			Expect(err).To(MatchError(ContainSubstring("fuse has blown")))
+		})
+
+		It("says that the calendar has changed since check", func() {
+			Expect(log).To(gbytes.Say("The calendar has changed since version sha256:0 was discovered"))
+		})
+	})
+
+	Context("in gate mode", func() {
+		BeforeEach(func() {
+			mode = "gate"
+
+			go func() {
+				defer GinkgoRecover()
+
+				Eventually(log).Should(gbytes.Say("freeze windows are currently active"))
+				Expect(os.WriteFile(calendar, []byte("freeze_calendar: []\n"), 0o644)).To(Succeed())
+			}()
+		})
+
+		It("opens once the calendar has changed", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(path.Join(destinationDir, "freeze-calendar.yaml")).To(BeAnExistingFile())
+		})
+	})
+})
+
+var _ = Describe("Get with a mistyped param", func() {
+	It("fails with a suggestion", func(ctx SpecContext) {
+		req := strings.NewReader(`{
+			"source": { "url": "file:///does/not/matter.yaml" },
+			"version": { "hash": "sha256:0" },
+			"params": { "mode": "gate", "retry_intervall": "1m" }
+		}`)
+
+		err := get.Get(ctx, req, &strings.Builder{}, GinkgoWriter, GinkgoT().TempDir())
+		Expect(err).To(MatchError(ContainSubstring("unknown key 'retry_intervall' in params; did you mean 'retry_interval'?")))
+	})
+})
@@ -0,0 +1,203 @@
+package get_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	timeMachine "github.com/benbjohnson/clock"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"github.com/homeport/freeze-calendar-resource/get"
+	"github.com/homeport/freeze-calendar-resource/resource"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Get with overrides", func() {
+	var (
+		err            error
+		origin         string
+		head           plumbing.Hash
+		mode           string
+		scope          string
+		logFormat      string
+		resp           strings.Builder
+		log            strings.Builder
+		destinationDir string
+		clock          *timeMachine.Mock
+	)
+
+	BeforeEach(func() {
+		tmpDir := GinkgoT().TempDir()
+		origin = path.Join(tmpDir, "remote")
+		destinationDir = path.Join(tmpDir, "resource-destination-directory")
+		resp = strings.Builder{}
+		log = strings.Builder{}
+		clock = timeMachine.NewMock()
+		clock.Set(time.Date(2023, time.August, 11, 19, 0, 0, 0, time.UTC))
+		mode = "fuse"
+		scope = `["eu-de"]`
+		logFormat = "text"
+
+		GinkgoT().Setenv("BUILD_TEAM_NAME", "main")
+		GinkgoT().Setenv("BUILD_PIPELINE_NAME", "shop")
+		GinkgoT().Setenv("BUILD_JOB_NAME", "deploy-eu-de")
+
+		repo, err := git.PlainInitWithOptions(origin, &git.PlainInitOptions{
+			InitOptions: git.InitOptions{DefaultBranch: plumbing.Main},
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		head, err = addAndCommit(repo, "calendar.yaml", []byte(`
+freeze_calendar:
+  - name: 2023 FIFA Women's World Cup
+    starts_at: 2023-07-20T09:00:00Z
+    ends_at: 2023-08-20T11:00:00Z
+overrides:
+  - reason: Hotfix for INC-4711
+    approver: jane.doe@example.com
+    expires_at: 2023-08-11T20:00:00Z
+    scope: [eu-de]
+    pipeline: shop
+`), "Create freeze calendar")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	JustBeforeEach(func(ctx SpecContext) {
+		req := strings.NewReader(fmt.Sprintf(`{
+			"source": { "uri": "%s", "path": "calendar.yaml" },
+			"version": { "sha": "%s" },
+			"params": { "mode": "%s", "scope": %s, "log_format": "%s" }
+		}`, origin, head, mode, scope, logFormat))
+
+		ctx2, cancel := context.WithTimeout(context.WithValue(ctx, get.ContextKeyClock, clock), 30*time.Second)
+		defer cancel()
+
+		err = get.Get(ctx2, req, &resp, &log, destinationDir)
+	})
+
+	Context("fuse mode with a matching override", func() {
+		It("succeeds", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("logs who approved the override", func() {
+			Expect(log.String()).To(ContainSubstring("approved by jane.doe@example.com"))
+		})
+
+		It("adds the override to the metadata", func() {
+			var response get.Response
+			Expect(json.NewDecoder(strings.NewReader(resp.String())).Decode(&response)).To(Succeed())
+			Expect(response.Metadata).To(ContainElements(
+				resource.NameValuePair{Name: "override approved by", Value: "jane.doe@example.com"},
+				resource.NameValuePair{Name: "override reason", Value: "Hotfix for INC-4711"},
+			))
+		})
+	})
+
+	Context("gate mode with a matching override", func() {
+		BeforeEach(func() {
+			mode = "gate"
+		})
+
+		It("passes right away", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("expired override", func() {
+		BeforeEach(func() {
+			clock.Set(time.Date(2023, time.August, 11, 20, 0, 1, 0, time.UTC))
+		})
+
+		It("blows the fuse", func() {
+			Expect(err).To(MatchError(ContainSubstring("fuse has blown")))
+		})
+	})
+
+	Context("expired override with JSON log format", func() {
+		BeforeEach(func() {
+			clock.Set(time.Date(2023, time.August, 11, 20, 0, 1, 0, time.UTC))
+			logFormat = "json"
+		})
+
+		It("logs the blown fuse as event", func() {
+			Expect(err).To(HaveOccurred())
+
+			var record map[string]any
+			lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+			Expect(json.Unmarshal([]byte(lines[len(lines)-1]), &record)).To(Succeed())
+			Expect(record).To(HaveKeyWithValue("time", "2023-08-11T20:00:01Z"))
+			Expect(record).To(HaveKeyWithValue("level", "error"))
+			Expect(record).To(HaveKeyWithValue("event", "fuse-blown"))
+			Expect(record).To(HaveKeyWithValue("windows", ConsistOf("2023 FIFA Women's World Cup")))
+			Expect(record).To(HaveKeyWithValue("scope", ConsistOf("eu-de")))
+			Expect(record).To(HaveKeyWithValue("head", head.String()))
+		})
+	})
+
+	Context("warn mode without a matching override", func() {
+		BeforeEach(func() {
+			mode = "warn"
+			scope = `["us-east"]`
+		})
+
+		It("succeeds", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("logs the active windows", func() {
+			Expect(log.String()).To(ContainSubstring("WARNING: Deploying anyway"))
+			Expect(log.String()).To(ContainSubstring("2023 FIFA Women's World Cup"))
+		})
+
+		It("adds the ignored windows to the metadata", func() {
+			var response get.Response
+			Expect(json.NewDecoder(strings.NewReader(resp.String())).Decode(&response)).To(Succeed())
+			Expect(response.Metadata).To(ContainElement(resource.NameValuePair{Name: "ignored freeze windows", Value: "2023 FIFA Women's World Cup (calendar.yaml)"}))
+		})
+
+		It("writes a marker file", func() {
+			contents, err := os.ReadFile(path.Join(destinationDir, get.StatusDirName, get.WarningFileName))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(string(contents)).To(HavePrefix("2023 FIFA Women's World Cup from "))
+		})
+	})
+
+	Context("warn mode with a matching override", func() {
+		BeforeEach(func() {
+			mode = "warn"
+		})
+
+		It("writes no marker file", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(path.Join(destinationDir, get.StatusDirName, get.WarningFileName)).ToNot(BeAnExistingFile())
+		})
+	})
+
+	Context("override for another pipeline", func() {
+		BeforeEach(func() {
+			GinkgoT().Setenv("BUILD_PIPELINE_NAME", "warehouse")
+		})
+
+		It("blows the fuse", func() {
+			Expect(err).To(MatchError(ContainSubstring("fuse has blown")))
+		})
+	})
+
+	Context("request for a scope beyond the override", func() {
+		BeforeEach(func() {
+			scope = `["eu-de", "us-east"]`
+		})
+
+		It("blows the fuse", func() {
+			Expect(err).To(MatchError(ContainSubstring("fuse has blown")))
+		})
+	})
+})
@@ -0,0 +1,96 @@
+package get_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	timeMachine "github.com/benbjohnson/clock"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"github.com/homeport/freeze-calendar-resource/get"
+	"github.com/homeport/freeze-calendar-resource/resource"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Get with multiple calendars", func() {
+	var (
+		err      error
+		mode     string
+		resp     strings.Builder
+		response get.Response
+	)
+
+	BeforeEach(func() {
+		mode = "fuse"
+	})
+
+	JustBeforeEach(func(ctx SpecContext) {
+		tmpDir := GinkgoT().TempDir()
+		origin := path.Join(tmpDir, "remote")
+		resp = strings.Builder{}
+
+		repo, initErr := git.PlainInitWithOptions(origin, &git.PlainInitOptions{
+			InitOptions: git.InitOptions{DefaultBranch: plumbing.Main},
+		})
+		Expect(initErr).ShouldNot(HaveOccurred())
+
+		_, err = addAndCommit(repo, "global.yaml", []byte(`
+freeze_calendar:
+  - name: Holiday Season
+    starts_at: 2023-12-01T06:00:00Z
+    ends_at: 2023-12-27T06:00:00Z
+`), "Create global freeze calendar")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		var head plumbing.Hash
+		head, err = addAndCommit(repo, "teams/payments.yaml", []byte(`
+freeze_calendar:
+  - name: Year-end Closing
+    starts_at: 2023-12-29T17:00:00Z
+    ends_at: 2024-01-02T05:00:00Z
+`), "Create freeze calendar of the payments team")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		req := strings.NewReader(fmt.Sprintf(`{
+			"source": { "uri": "%s", "path": "global.yaml", "paths": ["teams/*.yaml"] },
+			"version": { "sha": "%s" },
+			"params": { "mode": "%s" }
+		}`, origin, head, mode))
+
+		clock := timeMachine.NewMock()
+		clock.Set(time.Date(2023, time.December, 30, 12, 0, 0, 0, time.UTC))
+
+		var log strings.Builder
+		err = get.Get(context.WithValue(ctx, get.ContextKeyClock, clock), req, &resp, &log, path.Join(tmpDir, "destination"))
+
+		response = get.Response{}
+
+		if err == nil {
+			Expect(json.NewDecoder(strings.NewReader(resp.String())).Decode(&response)).To(Succeed())
+		}
+	})
+
+	It("blows the fuse with the window of any calendar", func() {
+		Expect(err).To(MatchError(ContainSubstring("Year-end Closing from 2023-12-29 17:00:00 +0000 UTC to 2024-01-02 05:00:00 +0000 UTC; defined in teams/payments.yaml")))
+	})
+
+	Context("warn mode", func() {
+		BeforeEach(func() {
+			mode = "warn"
+		})
+
+		It("counts the windows of all calendars", func() {
+			Expect(response.Metadata).To(ContainElement(resource.NameValuePair{Name: "total number of freeze windows", Value: "2"}))
+		})
+
+		It("names the file of each active window", func() {
+			Expect(response.Metadata).To(ContainElement(resource.NameValuePair{Name: "active freeze windows", Value: "Year-end Closing (teams/payments.yaml)"}))
+		})
+	})
+})
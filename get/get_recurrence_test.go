@@ -0,0 +1,97 @@
+package get_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	timeMachine "github.com/benbjohnson/clock"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"github.com/homeport/freeze-calendar-resource/get"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Get with a recurring window", func() {
+	var (
+		err            error
+		req            io.Reader
+		resp           strings.Builder
+		log            strings.Builder
+		destinationDir string
+		clock          *timeMachine.Mock
+		now            time.Time
+	)
+
+	BeforeEach(func() {
+		tmpDir := GinkgoT().TempDir()
+		origin := path.Join(tmpDir, "remote")
+		destinationDir = path.Join(tmpDir, "resource-destination-directory")
+		resp = strings.Builder{}
+		log = strings.Builder{}
+		clock = timeMachine.NewMock()
+
+		repo, err := git.PlainInitWithOptions(origin, &git.PlainInitOptions{
+			InitOptions: git.InitOptions{DefaultBranch: plumbing.Main},
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		head, err := addAndCommit(repo, "calendar.yaml", []byte(`
+freeze_calendar:
+  - name: Weekend
+    starts_at: 2023-09-01T15:00:00Z
+    ends_at: 2023-09-04T06:00:00Z
+    recurrence: FREQ=WEEKLY;BYDAY=FR
+`), "Create freeze calendar")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		req = strings.NewReader(fmt.Sprintf(`{
+			"source": {
+				"uri": "%s",
+				"path": "calendar.yaml"
+			},
+			"version": { "sha": "%s" },
+			"params": { "mode": "fuse", "runway": "1h" }
+		}`, origin, head))
+	})
+
+	JustBeforeEach(func(ctx SpecContext) {
+		clock.Set(now)
+		err = get.Get(context.WithValue(ctx, get.ContextKeyClock, clock), req, &resp, &log, destinationDir)
+	})
+
+	Context("on a Wednesday", func() {
+		BeforeEach(func() {
+			now = time.Date(2023, time.October, 11, 12, 0, 0, 0, time.UTC)
+		})
+
+		It("succeeds", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("on a later weekend", func() {
+		BeforeEach(func() {
+			now = time.Date(2023, time.October, 8, 12, 0, 0, 0, time.UTC)
+		})
+
+		It("blows the fuse", func() {
+			Expect(err).To(MatchError(ContainSubstring("fuse has blown")))
+		})
+	})
+
+	Context("with not enough runway before a weekend", func() {
+		BeforeEach(func() {
+			now = time.Date(2023, time.October, 13, 14, 30, 0, 0, time.UTC)
+		})
+
+		It("blows the fuse", func() {
+			Expect(err).To(MatchError(ContainSubstring("fuse has blown")))
+		})
+	})
+})
@@ -0,0 +1,157 @@
+package get_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	timeMachine "github.com/benbjohnson/clock"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+
+	"github.com/homeport/freeze-calendar-resource/get"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Get writing the freeze status", func() {
+	var (
+		origin         string
+		head           plumbing.Hash
+		calendar       string
+		destinationDir string
+		status         get.Status
+	)
+
+	BeforeEach(func() {
+		tmpDir := GinkgoT().TempDir()
+		origin = path.Join(tmpDir, "remote")
+		destinationDir = path.Join(tmpDir, "resource-destination-directory")
+		calendar = `
+freeze_calendar:
+  - name: Summer Break
+    starts_at: 2023-07-01T00:00:00Z
+    ends_at: 2023-08-01T00:00:00Z
+  - name: Weekend
+    starts_at: 2023-09-01T15:00:00Z
+    duration: 63h
+    recurrence: FREQ=WEEKLY;BYDAY=FR
+  - name: US Election
+    starts_at: 2023-10-11T14:00:00Z
+    ends_at: 2023-10-12T00:00:00Z
+    scope: [us-*]
+  - name: Oktoberfest
+    starts_at: 2023-10-11T16:00:00Z
+    ends_at: 2023-10-12T00:00:00Z
+    scope: [eu-de]
+`
+	})
+
+	JustBeforeEach(func(ctx SpecContext) {
+		repo, err := git.PlainInitWithOptions(origin, &git.PlainInitOptions{
+			InitOptions: git.InitOptions{DefaultBranch: plumbing.Main},
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		head, err = addAndCommit(repo, "calendar.yaml", []byte(calendar), "Create freeze calendar")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		req := strings.NewReader(fmt.Sprintf(`{
+			"source": { "uri": "%s", "path": "calendar.yaml" },
+			"version": { "sha": "%s" },
+			"params": { "mode": "fuse", "scope": ["eu-de"], "runway": "30m" }
+		}`, origin, head))
+
+		clock := timeMachine.NewMock()
+		clock.Set(time.Date(2023, time.October, 11, 12, 0, 0, 0, time.UTC))
+
+		var resp, log strings.Builder
+		err = get.Get(context.WithValue(ctx, get.ContextKeyClock, clock), req, &resp, &log, destinationDir)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		contents, err := os.ReadFile(path.Join(destinationDir, get.StatusDirName, get.StatusFileName))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(json.Unmarshal(contents, &status)).To(Succeed())
+	})
+
+	It("records how the calendar was evaluated", func() {
+		Expect(status.EvaluatedAt).To(Equal(time.Date(2023, time.October, 11, 12, 0, 0, 0, time.UTC)))
+		Expect(status.Runway.Duration).To(Equal(30 * time.Minute))
+		Expect(status.Scope).To(ConsistOf("eu-de"))
+		Expect(status.SHA).To(Equal(head.String()))
+	})
+
+	It("has the status of every window", func() {
+		Expect(status.Windows).To(HaveLen(4))
+
+		Expect(status.Windows[0].Name).To(Equal("Summer Break"))
+		Expect(status.Windows[0].Status).To(Equal(get.Past))
+		Expect(status.Windows[0].StartsIn).To(BeNil())
+		Expect(status.Windows[0].EndsIn).To(BeNil())
+
+		Expect(status.Windows[1].Name).To(Equal("Weekend"))
+		Expect(status.Windows[1].Status).To(Equal(get.Upcoming))
+		Expect(status.Windows[1].StartsAt).To(BeTemporally("==", time.Date(2023, time.October, 13, 15, 0, 0, 0, time.UTC)))
+		Expect(status.Windows[1].StartsIn.Duration).To(Equal(51 * time.Hour))
+
+		Expect(status.Windows[2].InScope).To(BeFalse())
+		Expect(status.Windows[3].InScope).To(BeTrue())
+		Expect(status.Windows[3].StartsIn.Duration).To(Equal(4 * time.Hour))
+	})
+
+	It("writes the next window within scope", func() {
+		name, err := os.ReadFile(path.Join(destinationDir, get.StatusDirName, get.NextWindowNameFileName))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(string(name)).To(Equal("Oktoberfest"))
+
+		startsAt, err := os.ReadFile(path.Join(destinationDir, get.StatusDirName, get.NextWindowStartFileName))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(string(startsAt)).To(Equal("2023-10-11T16:00:00Z"))
+	})
+
+	It("keeps the checkout of the calendar clean", func() {
+		repo, err := git.PlainOpen(destinationDir)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		worktree, err := repo.Worktree()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		// go-git's worktree cannot read from .git, so it does not pick up .git/info/exclude by itself
+		exclude, err := os.ReadFile(path.Join(destinationDir, ".git", "info", "exclude"))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		for line := range strings.Lines(string(exclude)) {
+			worktree.Excludes = append(worktree.Excludes, gitignore.ParsePattern(strings.TrimSpace(line), nil))
+		}
+
+		status, err := worktree.Status()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(status.IsClean()).To(BeTrue(), status.String())
+	})
+
+	Context("a window that is about to start", func() {
+		BeforeEach(func() {
+			calendar = `
+freeze_calendar:
+  - name: Weekend
+    starts_at: 2023-10-11T12:15:00Z
+    ends_at: 2023-10-11T13:00:00Z
+    scope: [us-east]
+`
+		})
+
+		It("is active because of the runway", func() {
+			Expect(status.Windows[0].Status).To(Equal(get.Active))
+			Expect(status.Windows[0].EndsIn.Duration).To(Equal(time.Hour))
+		})
+
+		It("writes no next window", func() {
+			Expect(path.Join(destinationDir, get.StatusDirName, get.NextWindowNameFileName)).ToNot(BeAnExistingFile())
+		})
+	})
+})
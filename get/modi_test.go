@@ -61,6 +61,20 @@ var _ = Describe("Modi", func() {
 		})
 	})
 
+	Context("warn mode", func() {
+		BeforeEach(func() {
+			request = strings.NewReader(`{"mode": "warn"}`)
+		})
+
+		It("works", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("has the expected mode", func() {
+			Expect(params.Mode).To(Equal(resource.Warn))
+		})
+	})
+
 	Context("unknown mode", func() {
 		BeforeEach(func() {
 			request = strings.NewReader(`{"mode": "foobar"}`)
@@ -0,0 +1,59 @@
+package get
+
+import (
+	"context"
+	"time"
+
+	timeMachine "github.com/benbjohnson/clock"
+)
+
+// RetryPolicy determines how gate mode polls the calendar repository and how it deals with failing fetches
+type RetryPolicy struct {
+	// Jitter is the fraction of the poll interval (and of the backoff) that is randomly added to it, so that gates
+	// polling the same repository do not hit the git server in lockstep, e.g. once it is back after an outage.
+	Jitter float64
+
+	// InitialBackoff is the wait after a failed fetch. It doubles with every consecutive failure, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// MaxConsecutiveErrors is the number of consecutive failed fetches after which the step gives up.
+	MaxConsecutiveErrors int
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	Jitter:               0.1,
+	InitialBackoff:       MinimumRetryInterval,
+	MaxBackoff:           5 * time.Minute,
+	MaxConsecutiveErrors: 5,
+}
+
+// Interval returns the poll interval with jitter added, given a random number in [0, 1)
+func (p RetryPolicy) Interval(interval time.Duration, random float64) time.Duration {
+	return interval + time.Duration(float64(interval)*p.Jitter*random)
+}
+
+// Backoff returns the wait after the given number of consecutive failures with jitter added, given a random number
+// in [0, 1)
+func (p RetryPolicy) Backoff(failures int, random float64) time.Duration {
+	backoff := p.InitialBackoff
+
+	for i := 1; i < failures && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	return p.Interval(min(backoff, p.MaxBackoff), random)
+}
+
+// sleep waits for d on the clock, or returns early if the context is done
+func sleep(ctx context.Context, clock timeMachine.Clock, d time.Duration) error {
+	timer := clock.Timer(d)
+
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
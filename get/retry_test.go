@@ -0,0 +1,41 @@
+package get_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/homeport/freeze-calendar-resource/get"
+)
+
+var _ = Describe("RetryPolicy", func() {
+	policy := get.RetryPolicy{
+		Jitter:               0.1,
+		InitialBackoff:       10 * time.Second,
+		MaxBackoff:           time.Minute,
+		MaxConsecutiveErrors: 5,
+	}
+
+	DescribeTable("poll interval",
+		func(random float64, expected time.Duration) {
+			Expect(policy.Interval(time.Minute, random)).To(Equal(expected))
+		},
+		Entry("without jitter", 0.0, time.Minute),
+		Entry("with half of the jitter", 0.5, time.Minute+3*time.Second),
+		Entry("with almost all of the jitter", 0.99, time.Minute+5940*time.Millisecond),
+	)
+
+	DescribeTable("backoff",
+		func(failures int, random float64, expected time.Duration) {
+			Expect(policy.Backoff(failures, random)).To(Equal(expected))
+		},
+		Entry("after the first failure", 1, 0.0, 10*time.Second),
+		Entry("after the second failure", 2, 0.0, 20*time.Second),
+		Entry("after the third failure", 3, 0.0, 40*time.Second),
+		Entry("capped", 4, 0.0, time.Minute),
+		Entry("still capped", 50, 0.0, time.Minute),
+		Entry("with half of the jitter", 2, 0.5, 21*time.Second),
+		Entry("capped, with almost all of the jitter", 4, 0.99, time.Minute+5940*time.Millisecond),
+	)
+})
@@ -0,0 +1,214 @@
+package get
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/homeport/freeze-calendar-resource/freeze"
+	"github.com/homeport/freeze-calendar-resource/resource"
+)
+
+// Files written into the StatusDirName directory of the destination, so that downstream tasks do not need to evaluate
+// the calendar themselves. The directory keeps them apart from the calendar repository's files, and out of its git status.
+const (
+	StatusDirName           = ".freeze"
+	StatusFileName          = "freeze-status.json"
+	NextWindowNameFileName  = "next_window_name"
+	NextWindowStartFileName = "next_window_starts_at"
+	WarningFileName         = "freeze-warning" // only in warn mode, if windows were active
+)
+
+// WindowState is where a window is at evaluation time; see freeze.State
+type WindowState = freeze.State
+
+const (
+	Active   = freeze.Active
+	Upcoming = freeze.Upcoming
+	Past     = freeze.Past
+)
+
+type Status struct {
+	EvaluatedAt      time.Time         `json:"evaluated_at"`
+	Runway           resource.Duration `json:"runway"`
+	Scope            []string          `json:"scope"`
+	resource.Version                   // of the calendar evaluated
+	Windows          []WindowStatus    `json:"windows"`
+}
+
+// WindowStatus describes the occurrence of a window that is relevant at evaluation time: the active one, or else the
+// next one, or else the last one.
+type WindowStatus struct {
+	Name     string             `json:"name"`
+	StartsAt time.Time          `json:"starts_at"`
+	EndsAt   time.Time          `json:"ends_at"`
+	Scope    []string           `json:"scope,omitempty"`
+	Origin   string             `json:"origin,omitempty"` // the file that defines the window
+	InScope  bool               `json:"in_scope"`
+	Status   WindowState        `json:"status"`
+	StartsIn *resource.Duration `json:"starts_in,omitempty"` // only for upcoming windows
+	EndsIn   *resource.Duration `json:"ends_in,omitempty"`   // only for active windows
+}
+
+// newStatus describes the statuses of the windows as they were evaluated at now (see freeze.Calendar.Evaluate)
+func newStatus(statuses freeze.Statuses, now time.Time, runway time.Duration, scope []string) Status {
+	status := Status{
+		EvaluatedAt: now,
+		Runway:      resource.Duration{Duration: runway},
+		Scope:       scope,
+		Windows:     []WindowStatus{},
+	}
+
+	for _, s := range statuses {
+		w := WindowStatus{
+			Name:     s.Name,
+			StartsAt: s.Start,
+			EndsAt:   s.End,
+			Scope:    s.Scope,
+			Origin:   s.Origin,
+			InScope:  s.InScope,
+			Status:   s.State,
+		}
+
+		switch s.State {
+		case Upcoming:
+			w.StartsIn = &resource.Duration{Duration: s.Start.Sub(now)}
+		case Active:
+			w.EndsIn = &resource.Duration{Duration: s.End.Sub(now)}
+		}
+
+		status.Windows = append(status.Windows, w)
+	}
+
+	return status
+}
+
+// Next returns the upcoming window within scope that starts first
+func (s Status) Next() (WindowStatus, bool) {
+	var next *WindowStatus
+
+	for i, w := range s.Windows {
+		if w.Status == Upcoming && w.InScope && (next == nil || w.StartsAt.Before(next.StartsAt)) {
+			next = &s.Windows[i]
+		}
+	}
+
+	if next == nil {
+		return WindowStatus{}, false
+	}
+
+	return *next, true
+}
+
+func writeStatus(destination string, status Status) error {
+	contents, err := json.MarshalIndent(status, "", "  ")
+
+	if err != nil {
+		return fmt.Errorf("unable to encode freeze status: %w", err)
+	}
+
+	files := map[string][]byte{StatusFileName: append(contents, '\n')}
+
+	if next, found := status.Next(); found {
+		files[NextWindowNameFileName] = []byte(next.Name)
+		files[NextWindowStartFileName] = []byte(next.StartsAt.Format(time.RFC3339))
+	}
+
+	dir, err := statusDir(destination)
+
+	if err != nil {
+		return err
+	}
+
+	for name, contents := range files {
+		err = os.WriteFile(filepath.Join(dir, name), contents, 0644)
+
+		if err != nil {
+			return fmt.Errorf("unable to write %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// writeWarning writes the marker file listing the active windows that warn mode ignored, one per line
+func writeWarning(destination string, windows []freeze.Window) error {
+	var contents strings.Builder
+
+	for _, w := range windows {
+		fmt.Fprintln(&contents, w)
+	}
+
+	dir, err := statusDir(destination)
+
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(filepath.Join(dir, WarningFileName), []byte(contents.String()), 0644)
+
+	if err != nil {
+		return fmt.Errorf("unable to write %s: %w", WarningFileName, err)
+	}
+
+	return nil
+}
+
+// statusDir creates the directory for the status files within destination and, if destination is a git worktree,
+// excludes it from git
+func statusDir(destination string) (string, error) {
+	dir := filepath.Join(destination, StatusDirName)
+	err := os.MkdirAll(dir, 0755)
+
+	if err != nil {
+		return "", fmt.Errorf("unable to create %s: %w", dir, err)
+	}
+
+	if info, err := os.Stat(filepath.Join(destination, ".git")); err != nil || !info.IsDir() {
+		return dir, nil
+	}
+
+	exclude := filepath.Join(destination, ".git", "info", "exclude")
+	pattern := "/" + StatusDirName
+	existing, err := os.ReadFile(exclude)
+
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("unable to read %s: %w", exclude, err)
+	}
+
+	if slices.Contains(strings.Split(string(existing), "\n"), pattern) {
+		return dir, nil
+	}
+
+	err = os.MkdirAll(filepath.Dir(exclude), 0755)
+
+	if err != nil {
+		return "", fmt.Errorf("unable to exclude %s from git: %w", StatusDirName, err)
+	}
+
+	file, err := os.OpenFile(exclude, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return "", fmt.Errorf("unable to exclude %s from git: %w", StatusDirName, err)
+	}
+
+	defer file.Close()
+
+	if len(existing) > 0 && !strings.HasSuffix(string(existing), "\n") {
+		pattern = "\n" + pattern
+	}
+
+	_, err = fmt.Fprintln(file, pattern)
+
+	if err != nil {
+		return "", fmt.Errorf("unable to exclude %s from git: %w", StatusDirName, err)
+	}
+
+	return dir, nil
+}
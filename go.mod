@@ -12,7 +12,7 @@ require (
 	github.com/orsinium-labs/enum v1.5.0
 	github.com/spf13/cobra v1.10.2
 	go.yaml.in/yaml/v3 v3.0.4
-	golang.org/x/exp v0.0.0-20260410095643-746e56fc9e2f
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,225 @@
+// Package keys finds keys in YAML and JSON documents that the types they are decoded into do not know, and suggests
+// the closest known key, e.g. ends_at for end_at.
+package keys
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// UnknownError is a key that is not known at path, e.g. params or freeze_calendar[2]
+type UnknownError struct {
+	Path  string
+	Key   string
+	Known []string
+}
+
+func (e UnknownError) Error() string {
+	message := fmt.Sprintf("unknown key '%s'", e.Key)
+
+	if e.Path != "" {
+		message += " in " + e.Path
+	}
+
+	if closest := Closest(e.Key, e.Known); closest != "" {
+		return fmt.Sprintf("%s; did you mean '%s'?", message, closest)
+	}
+
+	return fmt.Sprintf("%s; known keys are %s", message, strings.Join(e.Known, ", "))
+}
+
+// Field is a field of a struct type and the key it is known by in a document
+type Field struct {
+	Key string
+	reflect.StructField
+}
+
+// Fields returns the fields of a struct type in order, with their key by the given tag (e.g. yaml or json). Fields of
+// embedded structs without a tag are promoted, like encoding/json does; fields tagged with - are ignored. Untagged
+// fields are known by their name, which YAML lowercases.
+func Fields(typ reflect.Type, tag string) []Field {
+	var result []Field
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+
+		switch {
+		case name == "-":
+			continue
+		case field.Anonymous && name == "" && Struct(field.Type) != nil:
+			result = append(result, Fields(Struct(field.Type), tag)...)
+		case !field.IsExported():
+			continue
+		case name == "" && tag == "yaml":
+			result = append(result, Field{Key: strings.ToLower(field.Name), StructField: field})
+		case name == "":
+			result = append(result, Field{Key: field.Name, StructField: field})
+		default:
+			result = append(result, Field{Key: name, StructField: field})
+		}
+	}
+
+	return result
+}
+
+// Of returns the keys of the fields of a struct type by the given tag, and the type of each; see Fields
+func Of(typ reflect.Type, tag string) map[string]reflect.Type {
+	result := map[string]reflect.Type{}
+
+	for _, field := range Fields(typ, tag) {
+		result[field.Key] = field.Type
+	}
+
+	return result
+}
+
+// Struct returns the struct type typ is or points to, or the type of elements if typ is a slice of them; nil otherwise
+func Struct(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+	}
+
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return typ
+}
+
+// Sorted returns the keys of known in alphabetical order
+func Sorted(known map[string]reflect.Type) []string {
+	var result []string
+
+	for key := range known {
+		result = append(result, key)
+	}
+
+	slices.Sort(result)
+	return result
+}
+
+// Closest returns the known key that is most similar to key, if it is similar enough to be a typo, or that key is the
+// beginning of (e.g. ends for ends_at); "" otherwise
+func Closest(key string, known []string) string {
+	closest, best := "", max(2, len(key)/3)+1
+
+	for _, candidate := range known {
+		if d := distance(strings.ToLower(key), strings.ToLower(candidate)); d < best {
+			closest, best = candidate, d
+		}
+	}
+
+	if closest != "" || len(key) < 3 {
+		return closest
+	}
+
+	for _, candidate := range known {
+		if strings.HasPrefix(strings.ToLower(candidate), strings.ToLower(key)) {
+			return candidate
+		}
+	}
+
+	return ""
+}
+
+// distance is the Levenshtein distance of a and b
+func distance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+var jsonUnmarshaler = reflect.TypeFor[json.Unmarshaler]()
+
+// CheckJSON returns an UnknownError for each key of the JSON document data that v, a pointer, does not know
+func CheckJSON(data []byte, v any) []error {
+	var document any
+
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil // left to the actual decoding to report
+	}
+
+	return checkJSON(document, reflect.TypeOf(v), "")
+}
+
+func checkJSON(value any, typ reflect.Type, path string) []error {
+	if typ.Implements(jsonUnmarshaler) || reflect.PointerTo(typ).Implements(jsonUnmarshaler) {
+		return nil
+	}
+
+	var errs []error
+
+	switch value := value.(type) {
+	case []any:
+		if typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array {
+			for i, element := range value {
+				errs = append(errs, checkJSON(element, typ.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case map[string]any:
+		for typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+
+		if typ.Kind() != reflect.Struct {
+			return nil
+		}
+
+		known := Of(typ, "json")
+
+		for _, key := range sortedKeys(value) {
+			fieldType, found := known[key]
+
+			for k, t := range known {
+				if !found && strings.EqualFold(k, key) { // like encoding/json
+					fieldType, found = t, true
+				}
+			}
+
+			if !found {
+				errs = append(errs, UnknownError{Path: path, Key: key, Known: Sorted(known)})
+				continue
+			}
+
+			errs = append(errs, checkJSON(value[key], fieldType, strings.TrimPrefix(path+"."+key, "."))...)
+		}
+	}
+
+	return errs
+}
+
+func sortedKeys(m map[string]any) []string {
+	var result []string
+
+	for key := range m {
+		result = append(result, key)
+	}
+
+	slices.Sort(result)
+	return result
+}
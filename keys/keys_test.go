@@ -0,0 +1,62 @@
+package keys_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/homeport/freeze-calendar-resource/keys"
+)
+
+var _ = Describe("Keys", func() {
+	DescribeTable("closest",
+		func(key string, expected string) {
+			Expect(keys.Closest(key, []string{"ends_at", "retry_interval", "scope", "starts_at", "timezone"})).To(Equal(expected))
+		},
+		Entry("missing letter", "end_at", "ends_at"),
+		Entry("extra letter", "retry_intervall", "retry_interval"),
+		Entry("plural", "scopes", "scope"),
+		Entry("abbreviation", "retry", "retry_interval"),
+		Entry("different case", "TimeZone", "timezone"),
+		Entry("nothing alike", "colour", ""),
+	)
+
+	Describe("checking JSON", func() {
+		type embedded struct {
+			Source map[string]string `json:"source"`
+		}
+
+		type request struct {
+			embedded
+			Params struct {
+				RetryInterval string    `json:"retry_interval"`
+				Until         time.Time `json:"until"`
+			} `json:"params"`
+			Items []struct {
+				Name string `json:"name"`
+			} `json:"items"`
+		}
+
+		It("accepts known keys, including promoted ones and those of maps", func() {
+			Expect(keys.CheckJSON([]byte(`{
+				"source": { "anything": "goes" },
+				"params": { "retry_interval": "1m", "until": "2023-10-11T16:00:00Z" },
+				"items": [{ "name": "first" }]
+			}`), &request{})).To(BeEmpty())
+		})
+
+		It("reports unknown keys with their path and a suggestion", func() {
+			errs := keys.CheckJSON([]byte(`{
+				"params": { "retry_intervall": "1m" },
+				"items": [{ "name": "first" }, { "nmae": "second" }],
+				"extra": true
+			}`), &request{})
+
+			Expect(errs).To(HaveLen(3))
+			Expect(errs[0]).To(MatchError("unknown key 'extra'; known keys are items, params, source"))
+			Expect(errs[1]).To(MatchError("unknown key 'nmae' in items[1]; did you mean 'name'?"))
+			Expect(errs[2]).To(MatchError("unknown key 'retry_intervall' in params; did you mean 'retry_interval'?"))
+		})
+	})
+})
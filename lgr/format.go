@@ -0,0 +1,71 @@
+package lgr
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/orsinium-labs/enum"
+)
+
+type Format enum.Member[string]
+
+var (
+	Text    = Format{"text"}
+	JSON    = Format{"json"}
+	Logfmt  = Format{"logfmt"}
+	Formats = enum.New(Text, JSON, Logfmt)
+)
+
+type ContextKey string
+
+// ContextKeyFormat carries the format selected on the command line, which applies unless the request params select one
+const ContextKeyFormat = ContextKey("log-format")
+
+func (f *Format) UnmarshalJSON(b []byte) error {
+	rawFormat := string(b)
+	unquoted, err := strconv.Unquote(rawFormat)
+
+	if err != nil {
+		return fmt.Errorf("unable to unquote raw log format '%s': %w", rawFormat, err)
+	}
+
+	return f.Set(unquoted)
+}
+
+// String, Set and Type implement pflag.Value, so that a Format can be used as command line flag
+func (f *Format) String() string {
+	if f.Value == "" {
+		return Text.Value
+	}
+
+	return f.Value
+}
+
+func (f *Format) Set(s string) error {
+	parsed := Formats.Parse(s)
+
+	if parsed == nil {
+		return fmt.Errorf("'%s' is not a valid log format, valid ones are %s", s, Formats.String())
+	}
+
+	*f = *parsed
+	return nil
+}
+
+func (f *Format) Type() string {
+	return "format"
+}
+
+// FormatOf returns f if it was set, or the format carried by the context otherwise
+func FormatOf(ctx context.Context, f Format) Format {
+	if f.Value != "" {
+		return f
+	}
+
+	if value := ctx.Value(ContextKeyFormat); value != nil {
+		return *value.(*Format)
+	}
+
+	return Text
+}
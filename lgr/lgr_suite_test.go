@@ -0,0 +1,13 @@
+package lgr_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestLgr(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Lgr Suite")
+}
@@ -1,8 +1,13 @@
 package lgr
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type LogLevel int
@@ -14,27 +19,158 @@ const (
 	DebugLevel
 )
 
+func (l LogLevel) String() string {
+	switch l {
+	case FatalLevel:
+		return "fatal"
+	case ErrorLevel:
+		return "error"
+	case InfoLevel:
+		return "info"
+	default:
+		return "debug"
+	}
+}
+
+// Fields are attached to a record in the structured formats. Empty fields are omitted.
+type Fields struct {
+	Windows []string      `json:"windows,omitempty"`
+	Scope   []string      `json:"scope,omitempty"`
+	Head    string        `json:"head,omitempty"`
+	Runway  time.Duration `json:"runway,omitempty"`
+	Attempt int           `json:"attempt,omitempty"`
+}
+
 type Logger struct {
 	Level  LogLevel
 	Writer io.Writer
+	Format Format
+	Now    func() time.Time // defaults to time.Now
+
+	event  string
+	fields Fields
+}
+
+type record struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Event   string    `json:"event,omitempty"`
+	Message string    `json:"message"`
+	Runway  string    `json:"runway,omitempty"` // shadows Fields.Runway, which would be encoded as nanoseconds
+	Fields
+}
+
+// Event returns a logger that attaches the event name and fields to its records. In text format, both are ignored.
+func (l Logger) Event(name string, fields Fields) Logger {
+	l.event = name
+	l.fields = fields
+	return l
 }
 
 // Write provides compatibility with io.Writer. When using this method, Log levels are ignored and no newline will be added.
+// In the structured formats, data is written as debug record, and only if the level is debug.
 func (l Logger) Write(data []byte) (int, error) {
-	fmt.Fprint(l.Writer, string(data))
-	return 0, nil
+	if l.Structured() {
+		if message := strings.TrimSpace(string(data)); message != "" {
+			l.log(DebugLevel, "%s", message)
+		}
+	} else {
+		fmt.Fprint(l.Writer, string(data))
+	}
+
+	return len(data), nil
 }
 
 func (l Logger) Debug(format string, a ...any) {
-	if l.Level >= DebugLevel {
-		fmt.Fprintf(l.Writer, format, a...)
-		fmt.Fprintln(l.Writer)
-	}
+	l.log(DebugLevel, format, a...)
 }
 
 func (l Logger) Info(format string, a ...any) {
-	if l.Level >= InfoLevel {
+	l.log(InfoLevel, format, a...)
+}
+
+func (l Logger) Error(format string, a ...any) {
+	l.log(ErrorLevel, format, a...)
+}
+
+// Fatal logs regardless of the level and exits with status 1
+func (l Logger) Fatal(format string, a ...any) {
+	l.log(FatalLevel, format, a...)
+	os.Exit(1)
+}
+
+// Structured tells whether records are written as JSON or logfmt
+func (l Logger) Structured() bool {
+	return l.Format == JSON || l.Format == Logfmt
+}
+
+func (l Logger) log(level LogLevel, format string, a ...any) {
+	if l.Level < level {
+		return
+	}
+
+	if !l.Structured() {
 		fmt.Fprintf(l.Writer, format, a...)
 		fmt.Fprintln(l.Writer)
+		return
+	}
+
+	now := time.Now
+
+	if l.Now != nil {
+		now = l.Now
+	}
+
+	r := record{
+		Time:    now().UTC(),
+		Level:   level.String(),
+		Event:   l.event,
+		Message: fmt.Sprintf(format, a...),
+		Fields:  l.fields,
 	}
+
+	if l.fields.Runway != 0 {
+		r.Runway = l.fields.Runway.String()
+	}
+
+	if l.Format == JSON {
+		json.NewEncoder(l.Writer).Encode(r)
+	} else {
+		fmt.Fprintln(l.Writer, r.logfmt())
+	}
+}
+
+func (r record) logfmt() string {
+	pairs := []string{
+		"time=" + r.Time.Format(time.RFC3339Nano),
+		"level=" + r.Level,
+	}
+
+	add := func(key, value string) {
+		if value != "" {
+			pairs = append(pairs, key+"="+quote(value))
+		}
+	}
+
+	add("event", r.Event)
+	pairs = append(pairs, "message="+quote(r.Message))
+	add("windows", strings.Join(r.Windows, ","))
+	add("scope", strings.Join(r.Scope, ","))
+	add("head", r.Head)
+	add("runway", r.Runway)
+
+	if r.Attempt != 0 {
+		add("attempt", strconv.Itoa(r.Attempt))
+	}
+
+	return strings.Join(pairs, " ")
+}
+
+// quote quotes values that would otherwise not be parsed as a single logfmt value
+func quote(value string) string {
+	if value == "" || strings.ContainsAny(value, " =\"\\\n\t") {
+		return strconv.Quote(value)
+	}
+
+	return value
 }
@@ -0,0 +1,121 @@
+package lgr_test
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/homeport/freeze-calendar-resource/lgr"
+)
+
+var _ = Describe("Logger", func() {
+	var (
+		out    strings.Builder
+		logger lgr.Logger
+		fields lgr.Fields
+	)
+
+	BeforeEach(func() {
+		out = strings.Builder{}
+		logger = lgr.Logger{
+			Level:  lgr.InfoLevel,
+			Writer: &out,
+			Now:    func() time.Time { return time.Date(2023, time.August, 11, 19, 0, 0, 0, time.UTC) },
+		}
+		fields = lgr.Fields{
+			Windows: []string{"Holiday Season"},
+			Scope:   []string{"eu-de"},
+			Head:    "4711",
+			Runway:  30 * time.Minute,
+			Attempt: 2,
+		}
+	})
+
+	Context("text format", func() {
+		It("writes the message only", func() {
+			logger.Event("gate-waiting", fields).Info("waiting for %s", "Holiday Season")
+			Expect(out.String()).To(Equal("waiting for Holiday Season\n"))
+		})
+
+		It("honors the level", func() {
+			logger.Debug("hidden")
+			logger.Error("shown")
+			Expect(out.String()).To(Equal("shown\n"))
+		})
+
+		It("passes written data through", func() {
+			logger.Write([]byte("."))
+			Expect(out.String()).To(Equal("."))
+		})
+	})
+
+	Context("JSON format", func() {
+		BeforeEach(func() {
+			logger.Format = lgr.JSON
+		})
+
+		It("writes a record with typed fields", func() {
+			logger.Event("gate-waiting", fields).Info("waiting")
+
+			var record map[string]any
+			Expect(json.Unmarshal([]byte(out.String()), &record)).To(Succeed())
+			Expect(record).To(Equal(map[string]any{
+				"time":    "2023-08-11T19:00:00Z",
+				"level":   "info",
+				"event":   "gate-waiting",
+				"message": "waiting",
+				"windows": []any{"Holiday Season"},
+				"scope":   []any{"eu-de"},
+				"head":    "4711",
+				"runway":  "30m0s",
+				"attempt": float64(2),
+			}))
+		})
+
+		It("omits empty fields", func() {
+			logger.Error("failed")
+			Expect(out.String()).To(MatchJSON(`{"time":"2023-08-11T19:00:00Z","level":"error","message":"failed"}`))
+		})
+
+		It("writes data only at debug level", func() {
+			logger.Write([]byte("Counting objects\n"))
+			Expect(out.String()).To(BeEmpty())
+
+			logger.Level = lgr.DebugLevel
+			logger.Write([]byte("Counting objects\n"))
+			Expect(out.String()).To(MatchJSON(`{"time":"2023-08-11T19:00:00Z","level":"debug","message":"Counting objects"}`))
+		})
+	})
+
+	Context("logfmt format", func() {
+		BeforeEach(func() {
+			logger.Format = lgr.Logfmt
+		})
+
+		It("writes a line of key-value pairs", func() {
+			logger.Event("fuse-blown", fields).Error("fuse has blown")
+			Expect(out.String()).To(Equal(`time=2023-08-11T19:00:00Z level=error event=fuse-blown message="fuse has blown" windows="Holiday Season" scope=eu-de head=4711 runway=30m0s attempt=2` + "\n"))
+		})
+	})
+
+	DescribeTable("parsing the format",
+		func(raw string, expected lgr.Format, expectedError string) {
+			var format lgr.Format
+			err := json.Unmarshal([]byte(raw), &format)
+
+			if expectedError == "" {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(format).To(Equal(expected))
+			} else {
+				Expect(err).To(MatchError(ContainSubstring(expectedError)))
+			}
+		},
+		Entry("text", `"text"`, lgr.Text, ""),
+		Entry("json", `"json"`, lgr.JSON, ""),
+		Entry("logfmt", `"logfmt"`, lgr.Logfmt, ""),
+		Entry("unknown", `"xml"`, lgr.Format{}, "not a valid log format"),
+	)
+})
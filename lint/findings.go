@@ -0,0 +1,311 @@
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/homeport/freeze-calendar-resource/freeze"
+	"github.com/orsinium-labs/enum"
+)
+
+type Severity enum.Member[string]
+
+var (
+	Warning    = Severity{"warning"}
+	Error      = Severity{"error"}
+	Severities = enum.New(Warning, Error)
+)
+
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(s.Value)), nil
+}
+
+// Finding is a problem with a calendar, located in the file that defines it, if known
+type Finding struct {
+	Severity Severity `json:"severity"`
+	Rule     string   `json:"rule"` // e.g. duplicate-name
+	File     string   `json:"file,omitempty"`
+	freeze.Position
+	Windows []string `json:"windows,omitempty"` // names of the windows concerned, if any
+	Scope   []string `json:"scope,omitempty"`
+	Message string   `json:"message"`
+}
+
+// Location is the file and position of the finding, e.g. calendar.yaml:4:5, as far as they are known
+func (f Finding) Location() string {
+	location := f.File
+
+	if f.Line > 0 {
+		location += fmt.Sprintf(":%d", f.Line)
+	}
+
+	if f.Column > 0 {
+		location += fmt.Sprintf(":%d", f.Column)
+	}
+
+	return strings.TrimPrefix(location, ":")
+}
+
+func (f Finding) String() string {
+	message := fmt.Sprintf("%s%s: %s", strings.ToUpper(f.Severity.Value[:1]), f.Severity.Value[1:], f.Message)
+
+	if location := f.Location(); location != "" {
+		return location + ": " + message
+	}
+
+	return message
+}
+
+// Options configure the checks of Findings
+type Options struct {
+	Now         time.Time
+	MaxDuration time.Duration // windows longer than this are reported; zero disables the check
+	KnownScopes []string      // scopes not overlapping any of these are reported; empty disables the check
+}
+
+// recurring windows are checked for overlaps within this period after now
+const overlapHorizon = 366 * 24 * time.Hour
+
+// Findings checks the semantics of calendar, e.g. for duplicate names or overlapping windows
+func Findings(calendar *freeze.Calendar, options Options) []Finding {
+	var findings []Finding
+
+	for i, w := range calendar.Windows {
+		findings = append(findings, duplicateName(calendar.Windows[:i], w)...)
+		findings = append(findings, inPast(w, options.Now)...)
+		findings = append(findings, tooLong(w, options.MaxDuration)...)
+		findings = append(findings, unknownScopes(w.Scope, options.KnownScopes, fmt.Sprintf("window '%s'", w.Name), w.Origin, w.Position("scope"), w.Name)...)
+		findings = append(findings, overlapping(calendar.Windows[:i], w, options.Now)...)
+
+		for _, s := range freeze.UnmatchedScopes(w.Scope) {
+			findings = append(findings, Finding{
+				Severity: Warning,
+				Rule:     "scope-unmatched",
+				File:     w.Origin,
+				Position: w.Position("scope"),
+				Windows:  []string{w.Name},
+				Scope:    w.Scope,
+				Message:  fmt.Sprintf("scope '%s' of window '%s' excludes nothing, as none of the other scopes matches it", s, w.Name),
+			})
+		}
+	}
+
+	for _, o := range calendar.Overrides {
+		findings = append(findings, unknownScopes(o.Scope, options.KnownScopes, fmt.Sprintf("override approved by %s", o.Approver), o.Origin, o.Position("scope"))...)
+
+		for _, s := range freeze.UnmatchedScopes(o.Scope) {
+			findings = append(findings, Finding{
+				Severity: Warning,
+				Rule:     "scope-unmatched",
+				File:     o.Origin,
+				Position: o.Position("scope"),
+				Scope:    o.Scope,
+				Message:  fmt.Sprintf("scope '%s' of override approved by %s excludes nothing, as none of the other scopes matches it", s, o.Approver),
+			})
+		}
+	}
+
+	return findings
+}
+
+// duplicateName reports w if one of the previous windows has the same name. Occurrences of a recurring iCalendar event
+// that were moved become windows of their own with the event's name; they are not reported.
+func duplicateName(previous []freeze.Window, w freeze.Window) []Finding {
+	for _, p := range previous {
+		if p.Name != w.Name || replacesOccurrence(p, w) || replacesOccurrence(w, p) {
+			continue
+		}
+
+		return []Finding{{
+			Severity: Error,
+			Rule:     "duplicate-name",
+			File:     w.Origin,
+			Position: w.Position("name"),
+			Windows:  []string{w.Name},
+			Message:  fmt.Sprintf("there is more than one window named '%s'", w.Name),
+		}}
+	}
+
+	return nil
+}
+
+func replacesOccurrence(master, w freeze.Window) bool {
+	return master.Recurrence != nil && !w.ReplacesOccurrence.IsZero() && slices.ContainsFunc(master.Except, w.ReplacesOccurrence.Equal)
+}
+
+func inPast(w freeze.Window, now time.Time) []Finding {
+	if len(w.Upcoming(now, 1)) > 0 {
+		return nil
+	}
+
+	return []Finding{{
+		Severity: Warning,
+		Rule:     "in-past",
+		File:     w.Origin,
+		Position: w.Position(),
+		Windows:  []string{w.Name},
+		Message:  fmt.Sprintf("window '%s' is entirely in the past and can be removed", w.Name),
+	}}
+}
+
+func tooLong(w freeze.Window, maximum time.Duration) []Finding {
+	if maximum == 0 || w.End.Sub(w.Start) <= maximum {
+		return nil
+	}
+
+	return []Finding{{
+		Severity: Warning,
+		Rule:     "too-long",
+		File:     w.Origin,
+		Position: w.Position("ends_at", "duration"),
+		Windows:  []string{w.Name},
+		Message:  fmt.Sprintf("window '%s' lasts %s, longer than the maximum of %s; is the end (%s) correct?", w.Name, w.End.Sub(w.Start), maximum, w.End),
+	}}
+}
+
+// unknownScopes reports the including patterns of scope that overlap none of the known scopes
+func unknownScopes(scope, known []string, subject, file string, position freeze.Position, windows ...string) []Finding {
+	if len(known) == 0 {
+		return nil
+	}
+
+	var findings []Finding
+
+	for _, s := range scope {
+		if strings.HasPrefix(s, "!") || freeze.ScopesOverlap([]string{s}, known) {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Severity: Error,
+			Rule:     "scope-unknown",
+			File:     file,
+			Position: position,
+			Windows:  windows,
+			Scope:    scope,
+			Message:  fmt.Sprintf("scope '%s' of %s is none of the known scopes %s", s, subject, strings.Join(known, ", ")),
+		})
+	}
+
+	return findings
+}
+
+// overlapping reports previous windows with the same scope as w that overlap it. Of recurring windows, only the
+// occurrences up to a year from now are compared.
+func overlapping(previous []freeze.Window, w freeze.Window, now time.Time) []Finding {
+	var findings []Finding
+
+	for _, p := range previous {
+		if !sameScope(p.Scope, w.Scope) {
+			continue
+		}
+
+		if a, b, found := overlap(p, w, now); found {
+			findings = append(findings, Finding{
+				Severity: Warning,
+				Rule:     "overlap",
+				File:     w.Origin,
+				Position: w.Position(),
+				Windows:  []string{p.Name, w.Name},
+				Scope:    w.Scope,
+				Message:  fmt.Sprintf("windows '%s' (%s to %s) and '%s' (%s to %s) overlap", p.Name, a.Start, a.End, w.Name, b.Start, b.End),
+			})
+		}
+	}
+
+	return findings
+}
+
+func overlap(a, b freeze.Window, now time.Time) (freeze.Window, freeze.Window, bool) {
+	for x := range a.Occurrences() {
+		if a.Recurrence != nil && x.Start.After(now.Add(overlapHorizon)) {
+			break
+		}
+
+		if a.Recurrence != nil && x.End.Before(now) {
+			continue
+		}
+
+		for y := range b.Occurrences() {
+			if !y.Start.Before(x.End) || (b.Recurrence != nil && y.Start.After(now.Add(overlapHorizon))) {
+				break
+			}
+
+			if y.End.After(x.Start) {
+				return x, y, true
+			}
+		}
+	}
+
+	return freeze.Window{}, freeze.Window{}, false
+}
+
+func sameScope(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+
+	return slices.Equal(slices.Compact(a), slices.Compact(b))
+}
+
+// invalid turns an error loading the calendar in file into findings, one per problem that has a known position
+func invalid(file string, err error) []Finding {
+	var positioned freeze.PositionError
+
+	if !errors.As(err, &positioned) {
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			var findings []Finding
+
+			for _, e := range joined.Unwrap() {
+				findings = append(findings, invalid(file, e)...)
+			}
+
+			return findings
+		}
+
+		return []Finding{{Severity: Error, Rule: rule(err), File: file, Windows: windows(err), Message: err.Error()}}
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var findings []Finding
+
+		for _, e := range joined.Unwrap() {
+			findings = append(findings, invalid(file, e)...)
+		}
+
+		return findings
+	}
+
+	if err, ok := err.(freeze.PositionError); ok {
+		return []Finding{{Severity: Error, Rule: rule(err), File: file, Position: err.Position, Windows: windows(err), Message: err.Err.Error()}}
+	}
+
+	return invalid(file, errors.Unwrap(err))
+}
+
+// rule returns the rule that an error loading a calendar violates: zero-length for a window that does not end after
+// it starts, which only shows once its time zone is resolved, and invalid for anything else
+func rule(err error) string {
+	var zeroLength freeze.ZeroLengthError
+
+	if errors.As(err, &zeroLength) {
+		return "zero-length"
+	}
+
+	return "invalid"
+}
+
+// windows returns the name of the window that an error loading a calendar is about, if known
+func windows(err error) []string {
+	var zeroLength freeze.ZeroLengthError
+
+	if errors.As(err, &zeroLength) {
+		return []string{zeroLength.Window}
+	}
+
+	return nil
+}
@@ -0,0 +1,255 @@
+package lint_test
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/homeport/freeze-calendar-resource/freeze"
+	"github.com/homeport/freeze-calendar-resource/lint"
+)
+
+var _ = Describe("Findings", func() {
+	var (
+		yaml     string
+		options  lint.Options
+		findings []lint.Finding
+	)
+
+	rules := func(findings []lint.Finding) []string {
+		var result []string
+
+		for _, f := range findings {
+			result = append(result, f.Rule)
+		}
+
+		return result
+	}
+
+	BeforeEach(func() {
+		options = lint.Options{Now: time.Date(2023, time.October, 1, 12, 0, 0, 0, time.UTC)}
+	})
+
+	JustBeforeEach(func() {
+		calendar, err := freeze.Load("calendar.yaml", strings.NewReader(yaml))
+		Expect(err).ToNot(HaveOccurred())
+
+		findings = lint.Findings(calendar, options)
+	})
+
+	Context("of a sound calendar", func() {
+		BeforeEach(func() {
+			yaml = `
+freeze_calendar:
+  - name: Oktoberfest
+    starts_at: 2023-10-11T16:00:00Z
+    ends_at: 2023-10-12T00:00:00Z
+    scope: [eu-de]
+  - name: Thanksgiving
+    starts_at: 2023-11-23T00:00:00Z
+    ends_at: 2023-11-24T00:00:00Z
+    scope: [us]
+  - name: Patch Tuesday
+    starts_at: 2023-10-10T12:00:00Z
+    duration: 6h
+    recurrence: FREQ=MONTHLY;BYDAY=2TU
+    scope: [eu-de]
+`
+		})
+
+		It("has none", func() {
+			Expect(findings).To(BeEmpty())
+		})
+	})
+
+	Context("with duplicate names", func() {
+		BeforeEach(func() {
+			yaml = `
+freeze_calendar:
+  - name: Holiday Season
+    starts_at: 2023-12-20T00:00:00Z
+    ends_at: 2023-12-27T00:00:00Z
+  - name: Holiday Season
+    starts_at: 2023-12-31T00:00:00Z
+    ends_at: 2024-01-02T00:00:00Z
+`
+		})
+
+		It("reports an error", func() {
+			Expect(findings).To(HaveLen(1))
+			Expect(findings[0].Severity).To(Equal(lint.Error))
+			Expect(findings[0].String()).To(Equal("calendar.yaml:6:11: Error: there is more than one window named 'Holiday Season'"))
+		})
+	})
+
+	Context("with a one-off window named like a recurring one with exceptions", func() {
+		BeforeEach(func() {
+			yaml = `
+freeze_calendar:
+  - name: Release train
+    starts_at: 2023-10-02T08:00:00Z
+    ends_at: 2023-10-02T10:00:00Z
+    recurrence: FREQ=WEEKLY;COUNT=3
+    except: [2023-10-09T08:00:00Z]
+  - name: Release train
+    starts_at: 2023-10-10T08:00:00Z
+    ends_at: 2023-10-10T10:00:00Z
+`
+		})
+
+		It("reports a duplicate", func() {
+			Expect(rules(findings)).To(Equal([]string{"duplicate-name"}))
+		})
+	})
+
+	It("does not report moved occurrences of a recurring iCalendar event", func() {
+		calendar, err := freeze.Load("calendar.ics", strings.NewReader(strings.Join([]string{
+			"BEGIN:VCALENDAR",
+			"BEGIN:VEVENT",
+			"UID:train",
+			"SUMMARY:Release train",
+			"DTSTART:20231002T080000Z",
+			"DTEND:20231002T100000Z",
+			"RRULE:FREQ=WEEKLY;COUNT=3",
+			"END:VEVENT",
+			"BEGIN:VEVENT",
+			"UID:train",
+			"RECURRENCE-ID:20231009T080000Z",
+			"SUMMARY:Release train",
+			"DTSTART:20231010T080000Z",
+			"DTEND:20231010T100000Z",
+			"END:VEVENT",
+			"END:VCALENDAR",
+		}, "\n")))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(rules(lint.Findings(calendar, options))).ToNot(ContainElement("duplicate-name"))
+	})
+
+	Context("with overlapping windows", func() {
+		BeforeEach(func() {
+			yaml = `
+freeze_calendar:
+  - name: Oktoberfest
+    starts_at: 2023-10-11T16:00:00Z
+    ends_at: 2023-10-12T00:00:00Z
+    scope: [eu-de, eu-at]
+  - name: Reunification Day
+    starts_at: 2023-10-11T20:00:00Z
+    ends_at: 2023-10-13T00:00:00Z
+    scope: [eu-at, eu-de]
+  - name: Columbus Day
+    starts_at: 2023-10-11T20:00:00Z
+    ends_at: 2023-10-13T00:00:00Z
+    scope: [us]
+`
+		})
+
+		It("warns about those with the same scope", func() {
+			Expect(rules(findings)).To(Equal([]string{"overlap"}))
+			Expect(findings[0].Severity).To(Equal(lint.Warning))
+			Expect(findings[0].Windows).To(Equal([]string{"Oktoberfest", "Reunification Day"}))
+		})
+	})
+
+	Context("with a window overlapping an upcoming occurrence of a recurring one", func() {
+		BeforeEach(func() {
+			yaml = `
+freeze_calendar:
+  - name: Weekend
+    starts_at: 2023-01-06T18:00:00Z
+    ends_at: 2023-01-09T06:00:00Z
+    recurrence: FREQ=WEEKLY
+  - name: Release
+    starts_at: 2023-10-09T00:00:00Z
+    ends_at: 2023-10-09T12:00:00Z
+`
+		})
+
+		It("warns", func() {
+			Expect(rules(findings)).To(Equal([]string{"overlap"}))
+			Expect(findings[0].Message).To(ContainSubstring("2023-10-06 18:00:00 +0000 UTC to 2023-10-09 06:00:00 +0000 UTC"))
+		})
+	})
+
+	Context("with a window in the past", func() {
+		BeforeEach(func() {
+			yaml = `
+freeze_calendar:
+  - name: Easter
+    starts_at: 2023-04-07T00:00:00Z
+    ends_at: 2023-04-11T00:00:00Z
+  - name: Summer Fridays
+    starts_at: 2023-06-02T12:00:00Z
+    duration: 12h
+    recurrence: FREQ=WEEKLY;UNTIL=20230901T000000Z
+`
+		})
+
+		It("warns about each", func() {
+			Expect(rules(findings)).To(Equal([]string{"in-past", "in-past"}))
+			Expect(findings[0].String()).To(Equal("calendar.yaml:3:5: Warning: window 'Easter' is entirely in the past and can be removed"))
+		})
+	})
+
+	Context("with a very long window", func() {
+		BeforeEach(func() {
+			yaml = `
+freeze_calendar:
+  - name: Holiday Season
+    starts_at: 2023-12-20T00:00:00Z
+    ends_at: 2204-01-02T00:00:00Z
+`
+		})
+
+		It("has no finding by default", func() {
+			Expect(findings).To(BeEmpty())
+		})
+
+		Context("and a maximum", func() {
+			BeforeEach(func() {
+				options.MaxDuration = 31 * 24 * time.Hour
+			})
+
+			It("warns", func() {
+				Expect(rules(findings)).To(Equal([]string{"too-long"}))
+				Expect(findings[0].Message).To(ContainSubstring("is the end (2204-01-02 00:00:00 +0000 UTC) correct?"))
+			})
+		})
+	})
+
+	Context("with scopes", func() {
+		BeforeEach(func() {
+			yaml = `
+freeze_calendar:
+  - name: Oktoberfest
+    starts_at: 2023-10-11T16:00:00Z
+    ends_at: 2023-10-12T00:00:00Z
+    scope: [eu/de, eu-*, ue-nl, "!us-east"]
+overrides:
+  - reason: Hotfix for INC-4711
+    approver: jane.doe@example.com
+    expires_at: 2023-10-11T22:00:00Z
+    scope: [us-west]
+`
+		})
+
+		It("reports unmatched exclusions only", func() {
+			Expect(rules(findings)).To(Equal([]string{"scope-unmatched"}))
+		})
+
+		Context("and known scopes", func() {
+			BeforeEach(func() {
+				options.KnownScopes = []string{"eu", "eu-nl", "us-east"}
+			})
+
+			It("reports those that match none of them", func() {
+				Expect(rules(findings)).To(Equal([]string{"scope-unknown", "scope-unmatched", "scope-unknown"}))
+				Expect(findings[0].Message).To(Equal("scope 'ue-nl' of window 'Oktoberfest' is none of the known scopes eu, eu-nl, us-east"))
+				Expect(findings[2].Message).To(ContainSubstring("scope 'us-west' of override approved by jane.doe@example.com"))
+			})
+		})
+	})
+})
@@ -3,42 +3,145 @@ package lint
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/homeport/freeze-calendar-resource/freeze"
+	"github.com/homeport/freeze-calendar-resource/lgr"
 	"github.com/spf13/cobra"
 )
 
 var Verbose bool
 
+// Strict makes warnings fail the lint, too
+var Strict bool
+
+// MaxDuration is the longest a window may last without being reported; zero disables the check
+var MaxDuration time.Duration
+
+// KnownScopes are the scopes windows and overrides may use; if empty, any scope is allowed
+var KnownScopes []string
+
+// Output is the format findings are reported in
+var Output = TextOutput
+
+// IncludePaths maps URIs of included repositories to local directories, so that includes can be checked offline
+var IncludePaths map[string]string
+
+// number of concrete occurrences printed for recurring windows in verbose mode
+const upcomingOccurrences = 3
+
 func RunE(cmd *cobra.Command, args []string) error {
-	calendarFile, err := os.Open(args[0])
+	var calendars []*freeze.Calendar
+	var findings []Finding
 
-	if err != nil {
-		return fmt.Errorf("unable to read calendar file from path %s: %w", args[0], err)
+	for _, path := range args {
+		calendarFile, err := os.Open(path)
+
+		if err != nil {
+			return fmt.Errorf("unable to read calendar file from path %s: %w", path, err)
+		}
+
+		calendar, err := freeze.Load(path, calendarFile)
+		calendarFile.Close()
+
+		if err != nil {
+			findings = append(findings, invalid(path, err)...)
+			continue
+		}
+
+		calendars = append(calendars, calendar)
+	}
+
+	var calendar *freeze.Calendar
+
+	if len(findings) == 0 {
+		var err error
+		calendar, err = freeze.ResolveIncludes(freeze.Merge(calendars...), freeze.LocalFetcher(IncludePaths))
+
+		if err != nil {
+			findings = append(findings, Finding{Severity: Error, Rule: "invalid", Message: fmt.Sprintf("unable to resolve includes: %s", err)})
+		} else {
+			findings = Findings(calendar, Options{Now: time.Now(), MaxDuration: MaxDuration, KnownScopes: KnownScopes})
+		}
 	}
 
-	calendar, err := freeze.LoadCalendar(calendarFile)
+	err := write(cmd, findings)
 
 	if err != nil {
-		return fmt.Errorf("unable to load calendar: %w", err)
+		return fmt.Errorf("unable to write findings: %w", err)
 	}
 
-	if Verbose {
-		cmd.Print("Calendar is valid ")
-		switch len(calendar.Windows) {
-		case 0:
-			cmd.Print("but has no windows.")
-		case 1:
-			cmd.Print("and has one window:")
-		default:
-			cmd.Printf("and has %d windows:", len(calendar.Windows))
-		}
-		cmd.Println()
+	var errors, warnings int
 
-		for _, w := range calendar.Windows {
-			cmd.Println(w)
+	for _, finding := range findings {
+		if finding.Severity == Error {
+			errors++
+		} else {
+			warnings++
 		}
 	}
 
+	if errors > 0 || (Strict && warnings > 0) {
+		return fmt.Errorf("found %d errors and %d warnings", errors, warnings)
+	}
+
+	if Verbose && Output == TextOutput {
+		printCalendar(cmd, calendar, len(args))
+	}
+
 	return nil
 }
+
+// write reports the findings in the selected output format; text is logged, JSON and SARIF go to stdout
+func write(cmd *cobra.Command, findings []Finding) error {
+	switch Output {
+	case JSONOutput:
+		return writeJSON(cmd.OutOrStdout(), findings)
+	case SARIFOutput:
+		return writeSARIF(cmd.OutOrStdout(), findings)
+	default:
+		writeText(lgr.Logger{
+			Level:  lgr.InfoLevel,
+			Writer: cmd.ErrOrStderr(),
+			Format: lgr.FormatOf(cmd.Context(), lgr.Format{}),
+		}, findings)
+
+		return nil
+	}
+}
+
+// printCalendar lists the windows and overrides of the calendar merged from the given number of files
+func printCalendar(cmd *cobra.Command, calendar *freeze.Calendar, files int) {
+	has := "has"
+
+	if files == 1 {
+		cmd.Print("Calendar is valid ")
+	} else {
+		cmd.Printf("%d calendars are valid ", files)
+		has = "have"
+	}
+
+	switch len(calendar.Windows) {
+	case 0:
+		cmd.Printf("but %s no windows.", has)
+	case 1:
+		cmd.Printf("and %s one window:", has)
+	default:
+		cmd.Printf("and %s %d windows:", has, len(calendar.Windows))
+	}
+	cmd.Println()
+
+	for _, w := range calendar.Windows {
+		cmd.Println(w)
+
+		if w.Recurrence != nil {
+			for _, occurrence := range w.Upcoming(time.Now(), upcomingOccurrences) {
+				cmd.Printf("  next: %s to %s\n", occurrence.Start, occurrence.End)
+			}
+		}
+	}
+
+	for _, o := range calendar.Overrides {
+		cmd.Printf("Override %s\n", o)
+	}
+}
@@ -0,0 +1,187 @@
+package lint_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/homeport/freeze-calendar-resource/lint"
+)
+
+var _ = Describe("Lint", func() {
+	var (
+		err      error
+		calendar string
+		stdout   strings.Builder
+		stderr   strings.Builder
+	)
+
+	BeforeEach(func() {
+		calendar = path.Join(GinkgoT().TempDir(), "calendar.yaml")
+		stdout, stderr = strings.Builder{}, strings.Builder{}
+
+		Expect(os.WriteFile(calendar, []byte(`freeze_calendar:
+  - name: Holiday Season
+    starts_at: 2099-12-20T00:00:00Z
+    ends_at: 2099-12-27T00:00:00Z
+  - name: Oktoberfest
+    starts_at: 2023-10-11T16:00:00Z
+    ends_at: 2023-10-12T00:00:00Z
+`), 0o644)).To(Succeed())
+
+		DeferCleanup(func() {
+			lint.Output = lint.TextOutput
+			lint.Strict = false
+		})
+	})
+
+	JustBeforeEach(func() {
+		cmd := &cobra.Command{}
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetContext(context.Background())
+
+		err = lint.RunE(cmd, []string{calendar})
+	})
+
+	It("logs warnings with their position", func() {
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stderr.String()).To(Equal(calendar + ":5:5: Warning: window 'Oktoberfest' is entirely in the past and can be removed\n"))
+	})
+
+	Context("in strict mode", func() {
+		BeforeEach(func() {
+			lint.Strict = true
+		})
+
+		It("fails on warnings", func() {
+			Expect(err).To(MatchError("found 0 errors and 1 warnings"))
+		})
+	})
+
+	Context("with an invalid calendar", func() {
+		BeforeEach(func() {
+			Expect(os.WriteFile(calendar, []byte(`freeze_calendar:
+  - name: Holiday Season
+    starts_at: 2099-12-20T00:00:00Z
+  - name: Oktoberfest
+    starts_at: 2023-10-11T16:00:00Z
+    ends_at: some time in October
+`), 0o644)).To(Succeed())
+		})
+
+		It("reports the position of the problem", func() {
+			Expect(err).To(MatchError("found 1 errors and 0 warnings"))
+			Expect(stderr.String()).To(HavePrefix(calendar + ":6:14: Error: unable to parse ends_at"))
+		})
+
+		Context("that is otherwise valid", func() {
+			BeforeEach(func() {
+				Expect(os.WriteFile(calendar, []byte(`freeze_calendar:
+  - name: Holiday Season
+    starts_at: 2099-12-20T00:00:00Z
+  - starts_at: 2099-10-11T16:00:00Z
+    ends_at: 2099-10-12T00:00:00Z
+`), 0o644)).To(Succeed())
+			})
+
+			It("reports each failed validation", func() {
+				Expect(err).To(MatchError("found 2 errors and 0 warnings"))
+				Expect(stderr.String()).To(ContainSubstring(calendar + ":2:5: Error: Key: 'Calendar.Windows[0].End'"))
+				Expect(stderr.String()).To(ContainSubstring(calendar + ":4:5: Error: Key: 'Calendar.Windows[1].Name'"))
+			})
+		})
+	})
+
+	Context("with a window that ends when it starts once its time zone is resolved", func() {
+		BeforeEach(func() {
+			lint.Output = lint.JSONOutput
+
+			Expect(os.WriteFile(calendar, []byte(`freeze_calendar:
+  - name: Release
+    timezone: Europe/Berlin
+    starts_at: 2099-10-11T18:00:00
+    ends_at: 2099-10-11T16:00:00Z
+`), 0o644)).To(Succeed())
+		})
+
+		It("reports it as zero-length", func() {
+			Expect(err).To(MatchError("found 1 errors and 0 warnings"))
+			Expect(stdout.String()).To(MatchJSON(`[{
+				"severity": "error",
+				"rule": "zero-length",
+				"file": "` + calendar + `",
+				"line": 5,
+				"column": 14,
+				"windows": ["Release"],
+				"message": "window 'Release' ends at 2099-10-11 18:00:00 +0200 CEST, which is not after its start at 2099-10-11 18:00:00 +0200 CEST"
+			}]`))
+		})
+	})
+
+	Context("with JSON output", func() {
+		BeforeEach(func() {
+			lint.Output = lint.JSONOutput
+		})
+
+		It("writes the findings to stdout", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stderr.String()).To(BeEmpty())
+			Expect(stdout.String()).To(MatchJSON(`[{
+				"severity": "warning",
+				"rule": "in-past",
+				"file": "` + calendar + `",
+				"line": 5,
+				"column": 5,
+				"windows": ["Oktoberfest"],
+				"message": "window 'Oktoberfest' is entirely in the past and can be removed"
+			}]`))
+		})
+	})
+
+	Context("with SARIF output", func() {
+		var log struct {
+			Version string
+			Runs    []struct {
+				Results []struct {
+					RuleID    string
+					Level     string
+					Locations []struct {
+						PhysicalLocation struct {
+							ArtifactLocation struct{ URI string }
+							Region           struct{ StartLine, StartColumn int }
+						}
+					}
+				}
+			}
+		}
+
+		BeforeEach(func() {
+			lint.Output = lint.SARIFOutput
+		})
+
+		JustBeforeEach(func() {
+			Expect(json.Unmarshal([]byte(stdout.String()), &log)).To(Succeed())
+		})
+
+		It("writes a SARIF log to stdout", func() {
+			Expect(log.Version).To(Equal("2.1.0"))
+			Expect(log.Runs).To(HaveLen(1))
+			Expect(log.Runs[0].Results).To(HaveLen(1))
+
+			result := log.Runs[0].Results[0]
+			Expect(result.RuleID).To(Equal("in-past"))
+			Expect(result.Level).To(Equal("warning"))
+			Expect(result.Locations[0].PhysicalLocation.ArtifactLocation.URI).To(Equal(calendar))
+			Expect(result.Locations[0].PhysicalLocation.Region.StartLine).To(Equal(5))
+			Expect(result.Locations[0].PhysicalLocation.Region.StartColumn).To(Equal(5))
+		})
+	})
+})
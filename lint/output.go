@@ -0,0 +1,169 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/homeport/freeze-calendar-resource/lgr"
+	"github.com/orsinium-labs/enum"
+)
+
+type OutputFormat enum.Member[string]
+
+var (
+	TextOutput    = OutputFormat{"text"}
+	JSONOutput    = OutputFormat{"json"}
+	SARIFOutput   = OutputFormat{"sarif"}
+	OutputFormats = enum.New(TextOutput, JSONOutput, SARIFOutput)
+)
+
+// String, Set and Type implement pflag.Value, so that an OutputFormat can be used as command line flag
+func (f *OutputFormat) String() string {
+	if f.Value == "" {
+		return TextOutput.Value
+	}
+
+	return f.Value
+}
+
+func (f *OutputFormat) Set(s string) error {
+	parsed := OutputFormats.Parse(s)
+
+	if parsed == nil {
+		return fmt.Errorf("'%s' is not a valid output format, valid ones are %s", s, OutputFormats.String())
+	}
+
+	*f = *parsed
+	return nil
+}
+
+func (f *OutputFormat) Type() string {
+	return "format"
+}
+
+// rules describes each rule of a finding, e.g. for SARIF
+var rules = []struct{ ID, Description string }{
+	{"invalid", "The calendar cannot be loaded, e.g. because of a syntax error or a missing field"},
+	{"duplicate-name", "More than one window has the same name"},
+	{"zero-length", "A window ends before or when it starts"},
+	{"scope-unknown", "A scope matches none of the known scopes"},
+	{"overlap", "Windows with the same scope overlap"},
+	{"in-past", "A window has ended"},
+	{"too-long", "A window lasts longer than the maximum duration"},
+	{"scope-unmatched", "An exclusion does not match any of the other patterns of its scope"},
+}
+
+// writeText logs each finding; errors at error level, warnings at info level
+func writeText(logger lgr.Logger, findings []Finding) {
+	for _, finding := range findings {
+		event := logger.Event(finding.Rule, lgr.Fields{Windows: finding.Windows, Scope: finding.Scope})
+
+		if finding.Severity == Error {
+			event.Error("%s", finding)
+		} else {
+			event.Info("%s", finding)
+		}
+	}
+}
+
+func writeJSON(writer io.Writer, findings []Finding) error {
+	if findings == nil {
+		findings = []Finding{}
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(findings)
+}
+
+// writeSARIF writes the findings as SARIF 2.1.0 log, which code review tools use to annotate the offending lines
+func writeSARIF(writer io.Writer, findings []Finding) error {
+	type message struct {
+		Text string `json:"text"`
+	}
+
+	type region struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn,omitempty"`
+	}
+
+	type physicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region *region `json:"region,omitempty"`
+	}
+
+	type location struct {
+		PhysicalLocation physicalLocation `json:"physicalLocation"`
+	}
+
+	type result struct {
+		RuleID    string     `json:"ruleId"`
+		Level     string     `json:"level"`
+		Message   message    `json:"message"`
+		Locations []location `json:"locations,omitempty"`
+	}
+
+	type rule struct {
+		ID               string  `json:"id"`
+		ShortDescription message `json:"shortDescription"`
+	}
+
+	results := []result{}
+
+	for _, finding := range findings {
+		r := result{RuleID: finding.Rule, Level: finding.Severity.Value, Message: message{finding.Message}}
+
+		if finding.File != "" {
+			var l location
+			l.PhysicalLocation.ArtifactLocation.URI = finding.File
+
+			if finding.Line > 0 {
+				l.PhysicalLocation.Region = &region{StartLine: finding.Line, StartColumn: finding.Column}
+			}
+
+			r.Locations = []location{l}
+		}
+
+		results = append(results, r)
+	}
+
+	var driverRules []rule
+
+	for _, r := range rules {
+		driverRules = append(driverRules, rule{ID: r.ID, ShortDescription: message{r.Description}})
+	}
+
+	type driver struct {
+		Name           string `json:"name"`
+		InformationURI string `json:"informationUri"`
+		Rules          []rule `json:"rules"`
+	}
+
+	type run struct {
+		Tool struct {
+			Driver driver `json:"driver"`
+		} `json:"tool"`
+		Results []result `json:"results"`
+	}
+
+	var r run
+	r.Tool.Driver = driver{Name: "freeze-calendar", InformationURI: "https://github.com/homeport/freeze-calendar-resource", Rules: driverRules}
+	r.Results = results
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(struct {
+		Version string `json:"version"`
+		Schema  string `json:"$schema"`
+		Runs    []run  `json:"runs"`
+	}{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []run{r},
+	})
+}
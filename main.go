@@ -1,33 +1,80 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"os"
+	"time"
 
 	"github.com/homeport/freeze-calendar-resource/check"
+	"github.com/homeport/freeze-calendar-resource/export"
 	"github.com/homeport/freeze-calendar-resource/get"
+	"github.com/homeport/freeze-calendar-resource/lgr"
 	"github.com/homeport/freeze-calendar-resource/lint"
+	"github.com/homeport/freeze-calendar-resource/next"
 	"github.com/homeport/freeze-calendar-resource/put"
+	"github.com/homeport/freeze-calendar-resource/schema"
+	"github.com/homeport/freeze-calendar-resource/status"
 	"github.com/spf13/cobra"
 )
 
 func main() {
-	if err := NewRootCommand().Execute(); err != nil {
+	ctx := context.WithValue(context.Background(), lgr.ContextKeyFormat, &logFormat)
+
+	if err := NewRootCommand().ExecuteContext(ctx); err != nil {
+		var exitCoder interface{ ExitCode() int }
+
+		if errors.As(err, &exitCoder) {
+			os.Exit(exitCoder.ExitCode())
+		}
+
 		os.Exit(1)
 	}
 }
 
+var logFormat = lgr.Text
+
 var rootCommand = &cobra.Command{
 	Use:   "freeze-calendar",
 	Short: "Freeze Calendar Resource",
 }
 
 var lintCommand = cobra.Command{
-	Use:   "lint",
-	Short: "Checks syntax and semantics of a freeze calendar file",
-	Args:  cobra.ExactArgs(1),
+	Use:   "lint FILE...",
+	Short: "Checks syntax and semantics of freeze calendar files",
+	Args:  cobra.MinimumNArgs(1),
 	RunE:  lint.RunE,
 }
 
+var exportCommand = cobra.Command{
+	Use:   "export FILE",
+	Short: "Renders a freeze calendar file in another format, e.g. for subscribing to it in a calendar app",
+	Args:  cobra.ExactArgs(1),
+	RunE:  export.RunE,
+}
+
+var statusCommand = cobra.Command{
+	Use:   "status FILE",
+	Short: "Tells whether a freeze calendar file freezes a scope now (or at another time); exits with 3 if so",
+	Args:  cobra.ExactArgs(1),
+	RunE:  status.RunE,
+}
+
+var nextCommand = cobra.Command{
+	Use:   "next FILE",
+	Short: "Lists the windows of a freeze calendar file within a time range, by default the next 30 days",
+	Args:  cobra.ExactArgs(1),
+	RunE:  next.RunE,
+}
+
+var schemaCommand = cobra.Command{
+	Use:       "schema calendar|source|params",
+	Short:     "Prints the JSON Schema of freeze calendar files, or of the resource's source or params, e.g. for editors",
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: schema.Names(),
+	RunE:      schema.RunE,
+}
+
 var checkCommand = cobra.Command{
 	Use:   "check",
 	Short: "Fetches the latest freeze calendar and emit its version",
@@ -38,11 +85,12 @@ var checkCommand = cobra.Command{
 
 var getCommand = cobra.Command{
 	Use:   "get",
-	Short: "Fetches the latest version of the freeze calendar and, if within a freeze, fails or sleeps.",
+	Short: "Fetches the latest version of the freeze calendar and, if within a freeze, fails, sleeps or warns.",
 	Long: `Fetches the latest version of the freeze calendar and
 
 * If FUSE, the resource simply fails.
-* If GATE, the resource sleeps while the current date and time are within a freeze window. This is re-tried every INTERVAL.`,
+* If GATE, the resource sleeps while the current date and time are within a freeze window. This is re-tried every INTERVAL.
+* If WARN, the resource logs the active freeze windows, but succeeds.`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return get.Get(cmd.Context(), cmd.InOrStdin(), cmd.OutOrStdout(), cmd.ErrOrStderr(), args[0])
@@ -51,7 +99,7 @@ var getCommand = cobra.Command{
 
 var putCommand = cobra.Command{
 	Use:   "put",
-	Short: "no-op",
+	Short: "Adds, starts, extends or ends a freeze window and pushes the updated calendar",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return put.Put(cmd.Context(), cmd.InOrStdin(), cmd.OutOrStdout(), cmd.ErrOrStderr(), args[0])
@@ -59,9 +107,29 @@ var putCommand = cobra.Command{
 }
 
 func NewRootCommand() *cobra.Command {
+	rootCommand.PersistentFlags().Var(&logFormat, "log-format", "format of log records: text, json or logfmt; the log_format param takes precedence")
+
 	lintCommand.PersistentFlags().BoolVarP(&lint.Verbose, "verbose", "V", false, "verbose output")
+	lintCommand.Flags().StringToStringVar(&lint.IncludePaths, "include-path", nil, "resolve includes from URI against a local directory, e.g. git@github.com:corp/freeze=../freeze; may be repeated")
+	lintCommand.Flags().BoolVar(&lint.Strict, "strict", false, "fail on warnings, too")
+	lintCommand.Flags().DurationVar(&lint.MaxDuration, "max-duration", 31*24*time.Hour, "warn about windows lasting longer than this; 0 disables the check")
+	lintCommand.Flags().VarP(&lint.Output, "output", "o", "format of findings: text (logged to stderr), json or sarif (written to stdout)")
+	lintCommand.Flags().StringSliceVar(&lint.KnownScopes, "known-scope", nil, "report scopes that match none of these; may be repeated")
+
+	exportCommand.Flags().StringVarP(&export.Format, "format", "f", "ics", "output format; ics is the only one supported so far")
+	exportCommand.Flags().StringToStringVar(&export.IncludePaths, "include-path", nil, "resolve includes from URI against a local directory, e.g. git@github.com:corp/freeze=../freeze; may be repeated")
+
+	statusCommand.Flags().StringSliceVar(&status.Scope, "scope", nil, "scope to evaluate the calendar for, like the scope param of get; may be repeated")
+	statusCommand.Flags().DurationVar(&status.Runway, "runway", 0, "time needed for a deployment, like the runway param of get")
+	statusCommand.Flags().TimeVar(&status.At, "at", time.Time{}, []string{time.RFC3339}, "time to evaluate the calendar at, e.g. 2023-12-24T06:00:00Z (default now)")
+	statusCommand.Flags().StringToStringVar(&status.IncludePaths, "include-path", nil, "resolve includes from URI against a local directory, e.g. git@github.com:corp/freeze=../freeze; may be repeated")
+
+	nextCommand.Flags().StringSliceVar(&next.Scope, "scope", nil, "list only windows overlapping this scope; may be repeated")
+	nextCommand.Flags().TimeVar(&next.From, "from", time.Time{}, []string{time.RFC3339}, "start of the time range, e.g. 2023-12-01T00:00:00Z (default now)")
+	nextCommand.Flags().TimeVar(&next.Until, "until", time.Time{}, []string{time.RFC3339}, "end of the time range (default 30 days after --from)")
+	nextCommand.Flags().StringToStringVar(&next.IncludePaths, "include-path", nil, "resolve includes from URI against a local directory, e.g. git@github.com:corp/freeze=../freeze; may be repeated")
 
-	rootCommand.AddCommand(&lintCommand, &checkCommand, &getCommand, &putCommand)
+	rootCommand.AddCommand(&lintCommand, &exportCommand, &statusCommand, &nextCommand, &schemaCommand, &checkCommand, &getCommand, &putCommand)
 	rootCommand.SilenceUsage = true
 
 	return rootCommand
@@ -0,0 +1,57 @@
+package next
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/homeport/freeze-calendar-resource/freeze"
+	"github.com/spf13/cobra"
+)
+
+// DefaultRange is how far ahead windows are listed unless Until is given
+const DefaultRange = 30 * 24 * time.Hour
+
+// Scope restricts the windows listed to those overlapping it; empty means all scopes
+var Scope []string
+
+// From is the start of the time range to list windows in; zero means now
+var From time.Time
+
+// Until is the end of the time range to list windows in; zero means DefaultRange after From
+var Until time.Time
+
+// IncludePaths maps URIs of included repositories to local directories, as for lint
+var IncludePaths map[string]string
+
+func RunE(cmd *cobra.Command, args []string) error {
+	calendar, err := freeze.LoadFile(args[0], freeze.LocalFetcher(IncludePaths))
+
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+
+	from, until := From, Until
+
+	if from.IsZero() {
+		from = time.Now().Truncate(time.Second)
+	}
+
+	if until.IsZero() {
+		until = from.Add(DefaultRange)
+	}
+
+	windows := calendar.Between(from, until, Scope)
+
+	if len(windows) == 0 {
+		fmt.Fprintf(out, "No windows from %s until %s.\n", from, until)
+		return nil
+	}
+
+	for _, w := range windows {
+		fmt.Fprintln(out, w)
+	}
+
+	return nil
+}
@@ -0,0 +1,13 @@
+package next_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestNext(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Next Suite")
+}
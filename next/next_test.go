@@ -0,0 +1,101 @@
+package next_test
+
+import (
+	"context"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/homeport/freeze-calendar-resource/next"
+)
+
+var _ = Describe("Next", func() {
+	var (
+		err      error
+		calendar string
+		stdout   strings.Builder
+	)
+
+	BeforeEach(func() {
+		calendar = path.Join(GinkgoT().TempDir(), "calendar.yaml")
+		stdout = strings.Builder{}
+
+		Expect(os.WriteFile(calendar, []byte(`freeze_calendar:
+  - name: Oktoberfest
+    starts_at: 2023-10-11T16:00:00Z
+    ends_at: 2023-10-12T00:00:00Z
+    scope: [eu-de]
+  - name: Patch Tuesday
+    starts_at: 2023-01-10T12:00:00Z
+    duration: 6h
+    recurrence: FREQ=MONTHLY;BYDAY=2TU
+    scope: [us]
+`), 0o644)).To(Succeed())
+
+		next.From = time.Date(2023, time.October, 1, 0, 0, 0, 0, time.UTC)
+
+		DeferCleanup(func() {
+			next.From = time.Time{}
+			next.Until = time.Time{}
+			next.Scope = nil
+		})
+	})
+
+	JustBeforeEach(func() {
+		cmd := &cobra.Command{}
+		cmd.SetOut(&stdout)
+		cmd.SetContext(context.Background())
+
+		err = next.RunE(cmd, []string{calendar})
+	})
+
+	lines := func() []string {
+		return strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	}
+
+	It("lists the windows of the next 30 days", func() {
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lines()).To(HaveLen(2))
+		Expect(lines()[0]).To(HavePrefix("Patch Tuesday from 2023-10-10 12:00:00 +0000 UTC"))
+		Expect(lines()[1]).To(HavePrefix("Oktoberfest from 2023-10-11 16:00:00 +0000 UTC"))
+	})
+
+	Context("until a later time", func() {
+		BeforeEach(func() {
+			next.Until = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+		})
+
+		It("lists every occurrence", func() {
+			Expect(lines()).To(HaveLen(4))
+		})
+
+		Context("and with a scope", func() {
+			BeforeEach(func() {
+				next.Scope = []string{"eu-de"}
+			})
+
+			It("lists only windows overlapping it", func() {
+				Expect(lines()).To(HaveLen(1))
+				Expect(lines()[0]).To(HavePrefix("Oktoberfest"))
+			})
+		})
+	})
+
+	Context("without windows in the range", func() {
+		BeforeEach(func() {
+			next.From = time.Date(2023, time.October, 10, 18, 0, 0, 0, time.UTC)
+			next.Until = time.Date(2023, time.October, 11, 0, 0, 0, 0, time.UTC)
+		})
+
+		It("says so", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stdout.String()).To(Equal("No windows from 2023-10-10 18:00:00 +0000 UTC until 2023-10-11 00:00:00 +0000 UTC.\n"))
+		})
+	})
+})
@@ -0,0 +1,201 @@
+package put
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/homeport/freeze-calendar-resource/freeze"
+	"go.yaml.in/yaml/v3"
+)
+
+// edit applies the action of params to the calendar document. It works on the YAML node tree, so that comments and
+// formatting of the rest of the document are preserved. The result is validated like any other calendar.
+func edit(document []byte, params Params, now time.Time) ([]byte, error) {
+	calendar, err := freeze.LoadCalendar(bytes.NewReader(document))
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to load calendar: %w", err)
+	}
+
+	var root yaml.Node
+	err = yaml.Unmarshal(document, &root)
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse calendar: %w", err)
+	}
+
+	if len(root.Content) == 0 || root.Content[0].Tag == "!!null" {
+		root = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+
+	mapping := root.Content[0]
+
+	if mapping.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("calendar is not a mapping")
+	}
+
+	windows := value(mapping, "freeze_calendar")
+
+	if windows == nil || windows.Kind != yaml.SequenceNode {
+		windows = &yaml.Node{Kind: yaml.SequenceNode}
+		set(mapping, "freeze_calendar", windows)
+	}
+
+	var existing *freeze.Window
+	var node *yaml.Node
+
+	for i, w := range calendar.Windows {
+		if w.Name == params.Name {
+			existing = &calendar.Windows[i]
+			node = windows.Content[i]
+			break
+		}
+	}
+
+	switch params.Action {
+	case Add, Start:
+		if existing != nil {
+			return nil, fmt.Errorf("there is already a window named '%s'", params.Name)
+		}
+
+		start := now
+
+		if params.Action == Add {
+			if params.StartsAt.IsZero() {
+				return nil, fmt.Errorf("adding a window requires starts_at")
+			}
+
+			start = params.StartsAt
+		}
+
+		end, err := endOf(start, params)
+
+		if err != nil {
+			return nil, err
+		}
+
+		node = &yaml.Node{Kind: yaml.MappingNode}
+		set(node, "name", scalar(params.Name))
+		set(node, "starts_at", timestamp(start))
+		set(node, "ends_at", timestamp(end))
+
+		if len(params.Scope) > 0 {
+			scope := &yaml.Node{Kind: yaml.SequenceNode}
+
+			for _, s := range params.Scope {
+				scope.Content = append(scope.Content, scalar(s))
+			}
+
+			set(node, "scope", scope)
+		}
+
+		windows.Content = append(windows.Content, node)
+	case Extend, End:
+		if existing == nil {
+			return nil, fmt.Errorf("there is no window named '%s'", params.Name)
+		}
+
+		if existing.Recurrence != nil {
+			return nil, fmt.Errorf("window '%s' is recurring; only non-recurring windows can be changed", params.Name)
+		}
+
+		var end time.Time
+
+		if params.Action == Extend {
+			end, err = endOf(existing.End, params)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if !end.After(existing.End) {
+				return nil, fmt.Errorf("the new end %s of window '%s' is not after its current end %s", end, params.Name, existing.End)
+			}
+		} else {
+			if now.Before(existing.Start) {
+				return nil, fmt.Errorf("window '%s' has not started yet", params.Name)
+			}
+
+			if now.After(existing.End) {
+				return nil, fmt.Errorf("window '%s' has already ended at %s", params.Name, existing.End)
+			}
+
+			end = now
+		}
+
+		remove(node, "duration")
+		set(node, "ends_at", timestamp(end))
+	default:
+		return nil, fmt.Errorf("unknown action %s", params.Action)
+	}
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	err = encoder.Encode(&root)
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode calendar: %w", err)
+	}
+
+	_, err = freeze.LoadCalendar(bytes.NewReader(buffer.Bytes()))
+
+	if err != nil {
+		return nil, fmt.Errorf("the updated calendar is invalid: %w", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// endOf returns ends_at if given, or from plus duration
+func endOf(from time.Time, params Params) (time.Time, error) {
+	switch {
+	case !params.EndsAt.IsZero() && params.Duration.Duration != 0:
+		return time.Time{}, fmt.Errorf("only one of ends_at and duration may be given")
+	case !params.EndsAt.IsZero():
+		return params.EndsAt, nil
+	case params.Duration.Duration > 0:
+		return from.Add(params.Duration.Duration), nil
+	default:
+		return time.Time{}, fmt.Errorf("%s requires either ends_at or a positive duration", params.Action)
+	}
+}
+
+func value(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+func set(mapping *yaml.Node, key string, v *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1] = v
+			return
+		}
+	}
+
+	mapping.Content = append(mapping.Content, scalar(key), v)
+}
+
+func remove(mapping *yaml.Node, key string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+func scalar(v string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}
+}
+
+func timestamp(t time.Time) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!timestamp", Value: t.UTC().Format(time.RFC3339)}
+}
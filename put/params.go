@@ -0,0 +1,54 @@
+package put
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/homeport/freeze-calendar-resource/lgr"
+	"github.com/homeport/freeze-calendar-resource/resource"
+	"github.com/orsinium-labs/enum"
+)
+
+type Params struct {
+	Action      Action            `json:"action" validate:"required"`
+	Name        string            `json:"name" validate:"required"`
+	Path        string            `json:"path"` // the calendar to change; defaults to the source's path
+	StartsAt    time.Time         `json:"starts_at"`
+	EndsAt      time.Time         `json:"ends_at"`
+	Duration    resource.Duration `json:"duration"`
+	Scope       []string          `json:"scope"`
+	AuthorName  string            `json:"author_name"`
+	AuthorEmail string            `json:"author_email"`
+	Message     string            `json:"message"`
+	Verbose     bool              `json:"verbose"`
+	LogFormat   lgr.Format        `json:"log_format"`
+}
+
+type Action enum.Member[string]
+
+var (
+	Add     = Action{"add"}
+	Start   = Action{"start"}
+	Extend  = Action{"extend"}
+	End     = Action{"end"}
+	Actions = enum.New(Add, Start, Extend, End)
+)
+
+func (a *Action) UnmarshalJSON(b []byte) error {
+	rawAction := string(b)
+	unquoted, err := strconv.Unquote(rawAction)
+
+	if err != nil {
+		return fmt.Errorf("unable to unquote raw action '%s': %w", rawAction, err)
+	}
+
+	parsed := Actions.Parse(unquoted)
+
+	if parsed == nil {
+		return fmt.Errorf("%s is not a valid action, valid ones are %s", string(b), Actions.String())
+	}
+
+	*a = *parsed
+	return nil
+}
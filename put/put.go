@@ -1,18 +1,33 @@
 package put
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"path/filepath"
+	"strings"
+	"time"
 
+	timeMachine "github.com/benbjohnson/clock"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
 	"github.com/go-playground/validator/v10"
+	"github.com/homeport/freeze-calendar-resource/lgr"
 	"github.com/homeport/freeze-calendar-resource/resource"
 )
 
 type Request struct {
-	resource.Source `json:"source"`
-	Params          resource.Params `json:"params" validate:"required"`
+	resource.Request
+	Params Params `json:"params" validate:"required"`
 }
 
 type Response struct {
@@ -20,9 +35,23 @@ type Response struct {
 	Metadata []resource.NameValuePair `json:"metadata,omitempty"`
 }
 
+type ContextKey string
+
+const ContextKeyClock = ContextKey("clock")
+
+// how often a push is attempted if the remote branch has moved in the meantime
+const maxPushAttempts = 5
+
+const (
+	defaultAuthorName  = "Freeze Calendar Resource"
+	defaultAuthorEmail = "freeze-calendar-resource@users.noreply.github.com"
+)
+
+// Put changes a freeze window in the calendar at source.path according to the action in params, commits and pushes
+// the change. If the push is rejected because the branch has moved, the change is re-applied on top of the new head.
 func Put(ctx context.Context, req io.Reader, resp, log io.Writer, source string) error {
 	var request Request
-	err := json.NewDecoder(req).Decode(&request)
+	err := resource.DecodeRequest(req, &request)
 
 	if err != nil {
 		return fmt.Errorf("unable to build decoder: %w", err)
@@ -34,9 +63,123 @@ func Put(ctx context.Context, req io.Reader, resp, log io.Writer, source string)
 		return fmt.Errorf("unable to build validator: %w", err)
 	}
 
-	fmt.Fprintln(log, "no-op")
+	if request.Source.URL != "" {
+		return fmt.Errorf("unable to change the calendar at %s: only calendars in git can be changed", request.Source.URL)
+	}
+
+	calendarPath := cmp.Or(request.Params.Path, request.Source.Path)
+
+	if calendarPath == "" {
+		return errors.New("unable to determine the calendar to change: params.path is required if the source has no path")
+	}
+
+	if !request.Source.IsCalendar(calendarPath) {
+		return fmt.Errorf("unable to change %s: it is none of the source's calendars", calendarPath)
+	}
+
+	if strings.ContainsAny(calendarPath, "*?[") {
+		return fmt.Errorf("unable to change %s: the path of the calendar to change must not be a glob", calendarPath)
+	}
+
+	if ext := strings.ToLower(filepath.Ext(calendarPath)); ext == ".ics" || ext == ".ical" || ext == ".icalendar" {
+		return fmt.Errorf("unable to change %s: only YAML calendars can be changed", calendarPath)
+	}
+
+	auth, err := request.Source.Auth()
+
+	if err != nil {
+		return fmt.Errorf("unable to build authenticator: %w", err)
+	}
+
+	logLevel := lgr.InfoLevel
+
+	if request.Params.Verbose {
+		logLevel = lgr.DebugLevel
+	}
+
+	var clock timeMachine.Clock = timeMachine.New()
 
-	response := Response{} // no version as we don't put anything
+	if value := ctx.Value(ContextKeyClock); value != nil {
+		clock = value.(timeMachine.Clock)
+	}
+
+	logger := lgr.Logger{
+		Level:  logLevel,
+		Writer: log,
+		Format: lgr.FormatOf(ctx, request.Params.LogFormat),
+		Now:    clock.Now,
+	}
+
+	var branch = request.Source.Branch
+
+	if branch == "" {
+		branch = "main"
+		logger.Debug("No branch given; falling back to %s", branch)
+	}
+
+	repo, err := git.CloneContext(ctx, memory.NewStorage(), memfs.New(), &git.CloneOptions{
+		URL:           request.Source.URI,
+		ReferenceName: plumbing.ReferenceName(branch),
+		SingleBranch:  true,
+		Auth:          auth,
+		Progress:      logger,
+	})
+
+	if err != nil {
+		return fmt.Errorf("unable to clone: %w", err)
+	}
+
+	var commit plumbing.Hash
+
+	for attempt := 1; ; attempt++ {
+		commit, err = commitChange(repo, calendarPath, request, clock.Now().UTC())
+
+		if err != nil {
+			return err
+		}
+
+		err = repo.PushContext(ctx, &git.PushOptions{
+			Auth:     auth,
+			RefSpecs: []config.RefSpec{config.RefSpec(fmt.Sprintf("%[1]s:%[1]s", plumbing.NewBranchReferenceName(branch)))},
+			Progress: logger,
+		})
+
+		if err == nil {
+			break
+		}
+
+		if attempt >= maxPushAttempts {
+			return fmt.Errorf("unable to push %s: %w", commit, err)
+		}
+
+		head, fetchErr := fetchHead(ctx, repo, branch, auth, logger)
+
+		if fetchErr != nil {
+			return fmt.Errorf("unable to push %s: %w", commit, errors.Join(err, fetchErr))
+		}
+
+		if !errors.Is(err, git.ErrNonFastForwardUpdate) && !hasMoved(repo, commit, head) {
+			return fmt.Errorf("unable to push %s: %w", commit, err)
+		}
+
+		logger.Event("push-rejected", lgr.Fields{Windows: []string{request.Params.Name}, Head: commit.String(), Attempt: attempt + 1}).Info("Push of %s was rejected because %s has moved; re-applying the change on top of it (attempt %d of %d)", commit, branch, attempt+1, maxPushAttempts)
+
+		err = reset(repo, head)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	logger.Event("pushed", lgr.Fields{Windows: []string{request.Params.Name}, Scope: request.Params.Scope, Head: commit.String()}).Info("Pushed %s to %s", commit, branch)
+
+	response := Response{
+		Version: resource.Version{SHA: commit.String()},
+		Metadata: []resource.NameValuePair{
+			{Name: "action", Value: request.Params.Action.Value},
+			{Name: "window", Value: request.Params.Name},
+		},
+	}
 
 	err = json.NewEncoder(resp).Encode(response)
 
@@ -46,3 +189,117 @@ func Put(ctx context.Context, req io.Reader, resp, log io.Writer, source string)
 
 	return nil
 }
+
+// commitChange edits the calendar at calendarPath in the worktree and commits the result
+func commitChange(repo *git.Repository, calendarPath string, request Request, now time.Time) (plumbing.Hash, error) {
+	worktree, err := repo.Worktree()
+
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("unable to get worktree: %w", err)
+	}
+
+	document, err := util.ReadFile(worktree.Filesystem, calendarPath)
+
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("unable to read calendar file from path %s: %w", calendarPath, err)
+	}
+
+	updated, err := edit(document, request.Params, now)
+
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("unable to %s window '%s': %w", request.Params.Action.Value, request.Params.Name, err)
+	}
+
+	err = util.WriteFile(worktree.Filesystem, calendarPath, updated, 0o644)
+
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("unable to write calendar file to path %s: %w", calendarPath, err)
+	}
+
+	_, err = worktree.Add(calendarPath)
+
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("unable to add %s: %w", calendarPath, err)
+	}
+
+	author := object.Signature{
+		Name:  request.Params.AuthorName,
+		Email: request.Params.AuthorEmail,
+		When:  now,
+	}
+
+	if author.Name == "" {
+		author.Name = defaultAuthorName
+	}
+
+	if author.Email == "" {
+		author.Email = defaultAuthorEmail
+	}
+
+	message := request.Params.Message
+
+	if message == "" {
+		message = fmt.Sprintf("%s%s freeze window '%s'", strings.ToUpper(request.Params.Action.Value[:1]), request.Params.Action.Value[1:], request.Params.Name)
+	}
+
+	commit, err := worktree.Commit(message, &git.CommitOptions{Author: &author})
+
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("unable to commit: %w", err)
+	}
+
+	return commit, nil
+}
+
+// fetchHead fetches branch and returns its head on the remote
+func fetchHead(ctx context.Context, repo *git.Repository, branch string, auth transport.AuthMethod, logger lgr.Logger) (plumbing.Hash, error) {
+	err := repo.FetchContext(ctx, &git.FetchOptions{
+		Auth:     auth,
+		Progress: logger,
+	})
+
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return plumbing.ZeroHash, fmt.Errorf("fetch failed: %w", err)
+	}
+
+	remoteHead, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", branch), true)
+
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("unable to resolve reference to branch %s: %w", branch, err)
+	}
+
+	return remoteHead.Hash(), nil
+}
+
+// hasMoved tells whether the remote head is no longer the one commit was made on top of, i.e. whether its push was
+// rejected as non-fast-forward. go-git only wraps git.ErrNonFastForwardUpdate for pulls; rejected pushes are reported
+// in messages that differ between client and server, so the remote head is compared instead.
+func hasMoved(repo *git.Repository, commit, head plumbing.Hash) bool {
+	c, err := repo.CommitObject(commit)
+
+	if err != nil || c.NumParents() != 1 {
+		return false
+	}
+
+	return c.ParentHashes[0] != head
+}
+
+// reset drops the local commit and resets to the remote head, so that the change can be re-applied on top of it
+func reset(repo *git.Repository, head plumbing.Hash) error {
+	worktree, err := repo.Worktree()
+
+	if err != nil {
+		return fmt.Errorf("unable to get worktree: %w", err)
+	}
+
+	err = worktree.Reset(&git.ResetOptions{
+		Commit: head,
+		Mode:   git.HardReset,
+	})
+
+	if err != nil {
+		return fmt.Errorf("resetting the workspace failed: %w", err)
+	}
+
+	return nil
+}
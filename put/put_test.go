@@ -1,71 +1,419 @@
 package put_test
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"os"
+	"path"
 	"strings"
+	"sync"
+	"time"
 
+	timeMachine "github.com/benbjohnson/clock"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/homeport/freeze-calendar-resource/freeze"
 	"github.com/homeport/freeze-calendar-resource/put"
 )
 
 var _ = Describe("Put", func() {
 	var (
-		err    error
-		req    io.Reader
-		resp   strings.Builder
-		log    strings.Builder
-		tmpDir string
+		err      error
+		req      io.Reader
+		resp     strings.Builder
+		log      strings.Builder
+		tmpDir   string
+		origin   string
+		clock    *timeMachine.Mock
+		putClock timeMachine.Clock // the clock given to Put
+		params   string
+		paths    string
+		response put.Response
 	)
 
 	BeforeEach(func() {
 		tmpDir = GinkgoT().TempDir()
-		req = strings.NewReader(`{
-		  "source": {
-        "uri": "https://github.com/homeport/freeze-calendar-resource",
-        "path": "examples/freeze-calendar.yaml"
-      },
-      "version": { "sha": "56dd3927d2582a332cacd5c282629293cd9a8870" },
-      "params": { "mode": "fuse", "scope": ["eu-de"] }
-    }`)
-
+		origin = path.Join(GinkgoT().TempDir(), "remote")
 		resp = strings.Builder{}
 		log = strings.Builder{}
+		response = put.Response{}
+		paths = "[]"
+		clock = timeMachine.NewMock()
+		clock.Set(time.Date(2023, time.December, 1, 12, 0, 0, 0, time.UTC))
+		putClock = clock
+
+		Expect(createOrigin(origin, "calendar.yaml", `# Maintained by the release managers
+freeze_calendar:
+  - name: Holiday Season
+    starts_at: 2023-11-20T06:00:00Z
+    ends_at: 2023-12-27T06:00:00Z
+    scope:
+      - eu-de
+`)).To(Succeed())
 	})
 
 	JustBeforeEach(func(ctx SpecContext) {
-		err = put.Put(ctx, req, &resp, &log, tmpDir)
+		req = strings.NewReader(fmt.Sprintf(`{
+			"source": {
+				"uri": "%s",
+				"path": "calendar.yaml",
+				"paths": %s
+			},
+			"params": %s
+		}`, origin, paths, params))
+
+		err = put.Put(context.WithValue(ctx, put.ContextKeyClock, putClock), req, &resp, &log, tmpDir)
+
+		if err == nil {
+			Expect(json.NewDecoder(strings.NewReader(resp.String())).Decode(&response)).To(Succeed())
+		}
 	})
 
-	It("executes successfully", func() {
-		Expect(err).ShouldNot(HaveOccurred())
+	Context("adding a window", func() {
+		BeforeEach(func() {
+			params = `{
+				"action": "add",
+				"name": "Year-end closing",
+				"starts_at": "2023-12-29T17:00:00Z",
+				"duration": "84h",
+				"scope": ["us-east"],
+				"author_name": "Jane Doe",
+				"author_email": "jane.doe@example.com"
+			}`
+		})
+
+		It("executes successfully", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("returns the new commit as version", func() {
+			Expect(response.Version.SHA).To(Equal(headOf(origin).Hash.String()))
+		})
+
+		It("commits as the configured author", func() {
+			Expect(headOf(origin).Author.Name).To(Equal("Jane Doe"))
+		})
+
+		It("has a default commit message", func() {
+			Expect(headOf(origin).Message).To(Equal("Add freeze window 'Year-end closing'"))
+		})
+
+		It("adds the window", func() {
+			calendar := calendarAt(origin, "calendar.yaml")
+			Expect(calendar.Windows).To(HaveLen(2))
+			Expect(calendar.Windows[1].Name).To(Equal("Year-end closing"))
+			Expect(calendar.Windows[1].End).To(Equal(time.Date(2024, time.January, 2, 5, 0, 0, 0, time.UTC)))
+			Expect(calendar.Windows[1].Scope).To(HaveExactElements("us-east"))
+		})
+
+		It("keeps comments", func() {
+			Expect(fileAt(origin, "calendar.yaml")).To(HavePrefix("# Maintained by the release managers\n"))
+		})
+
+		It("leaves the source directory alone", func() {
+			entries, err := os.ReadDir(tmpDir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(BeEmpty())
+		})
+
+		Context("with an existing name", func() {
+			BeforeEach(func() {
+				params = `{ "action": "add", "name": "Holiday Season", "starts_at": "2023-12-29T17:00:00Z", "duration": "1h" }`
+			})
+
+			It("fails", func() {
+				Expect(err).To(MatchError(ContainSubstring("there is already a window named 'Holiday Season'")))
+			})
+		})
 	})
 
-	Context("response", func() {
-		var response put.Response
+	Context("when the branch moves between clone and push", func() {
+		BeforeEach(func() {
+			params = `{ "action": "add", "name": "Year-end closing", "starts_at": "2023-12-29T17:00:00Z", "duration": "84h" }`
+
+			// Put asks for the time first when committing, i.e. after it has cloned
+			putClock = &hookedClock{Mock: clock, hook: func() {
+				Expect(commitToOrigin(origin, "calendar.yaml", fileAt(origin, "calendar.yaml")+`  - name: Oktoberfest
+    starts_at: 2023-10-11T16:00:00Z
+    ends_at: 2023-10-12T00:00:00Z
+`)).To(Succeed())
+			}}
+		})
 
-		JustBeforeEach(func() {
-			err = json.NewDecoder(strings.NewReader(resp.String())).Decode(&response)
+		It("executes successfully", func() {
+			Expect(err).ShouldNot(HaveOccurred())
 		})
 
-		It("is valid JSON", func() {
-			Expect(err).NotTo(HaveOccurred())
+		It("re-applies the change on top of the other one", func() {
+			Expect(headOf(origin).Message).To(Equal("Add freeze window 'Year-end closing'"))
+			Expect(headOf(origin).ParentHashes).To(HaveLen(1))
+
+			calendar := calendarAt(origin, "calendar.yaml")
+			Expect(calendar.Windows).To(HaveLen(3))
+			Expect(calendar.Windows[1].Name).To(Equal("Oktoberfest"))
+			Expect(calendar.Windows[2].Name).To(Equal("Year-end closing"))
+		})
+
+		It("logs the attempt", func() {
+			Expect(log.String()).To(ContainSubstring("re-applying the change on top of it (attempt 2 of 5)"))
 		})
 	})
 
-	Context("source directory", func() {
-		var entries []os.DirEntry
+	Context("starting a window", func() {
+		BeforeEach(func() {
+			params = `{ "action": "start", "name": "Incident", "duration": "2h", "message": "Freeze for INC-4711" }`
+		})
 
-		JustBeforeEach(func() {
-			entries, err = os.ReadDir(tmpDir)
-			Expect(err).ToNot(HaveOccurred())
+		It("executes successfully", func() {
+			Expect(err).ShouldNot(HaveOccurred())
 		})
 
-		It("is empty", func() {
-			Expect(entries).To(BeEmpty())
+		It("uses the configured message", func() {
+			Expect(headOf(origin).Message).To(Equal("Freeze for INC-4711"))
+		})
+
+		It("starts the window now", func() {
+			calendar := calendarAt(origin, "calendar.yaml")
+			Expect(calendar.Windows[1].Start).To(Equal(clock.Now().UTC()))
+			Expect(calendar.Windows[1].End).To(Equal(clock.Now().UTC().Add(2 * time.Hour)))
+		})
+	})
+
+	Context("extending a window", func() {
+		BeforeEach(func() {
+			params = `{ "action": "extend", "name": "Holiday Season", "duration": "24h" }`
+		})
+
+		It("executes successfully", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("moves the end", func() {
+			Expect(calendarAt(origin, "calendar.yaml").Windows[0].End).To(Equal(time.Date(2023, time.December, 28, 6, 0, 0, 0, time.UTC)))
+		})
+
+		Context("to an earlier end", func() {
+			BeforeEach(func() {
+				params = `{ "action": "extend", "name": "Holiday Season", "ends_at": "2023-12-24T00:00:00Z" }`
+			})
+
+			It("fails", func() {
+				Expect(err).To(MatchError(ContainSubstring("is not after its current end")))
+			})
+		})
+
+		Context("that does not exist", func() {
+			BeforeEach(func() {
+				params = `{ "action": "extend", "name": "Easter", "duration": "24h" }`
+			})
+
+			It("fails", func() {
+				Expect(err).To(MatchError(ContainSubstring("there is no window named 'Easter'")))
+			})
+		})
+	})
+
+	Context("ending a window", func() {
+		BeforeEach(func() {
+			params = `{ "action": "end", "name": "Holiday Season" }`
+		})
+
+		It("executes successfully", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("ends the window now", func() {
+			Expect(calendarAt(origin, "calendar.yaml").Windows[0].End).To(Equal(clock.Now().UTC()))
+		})
+
+		Context("that has already ended", func() {
+			BeforeEach(func() {
+				clock.Set(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+			})
+
+			It("fails", func() {
+				Expect(err).To(MatchError(ContainSubstring("has already ended")))
+			})
+		})
+	})
+
+	Context("changing a calendar that is not the source's path", func() {
+		BeforeEach(func() {
+			params = `{ "action": "end", "name": "Holiday Season", "path": "teams/payments.yaml" }`
+		})
+
+		It("fails if it is none of the source's calendars", func() {
+			Expect(err).To(MatchError(ContainSubstring("teams/payments.yaml: it is none of the source's calendars")))
+		})
+
+		Context("with a glob", func() {
+			BeforeEach(func() {
+				paths = `["teams/*.yaml"]`
+				params = `{ "action": "end", "name": "Holiday Season", "path": "teams/*.yaml" }`
+			})
+
+			It("fails", func() {
+				Expect(err).To(MatchError(ContainSubstring("must not be a glob")))
+			})
+		})
+	})
+
+	Context("unknown action", func() {
+		BeforeEach(func() {
+			params = `{ "action": "postpone", "name": "Holiday Season" }`
+		})
+
+		It("fails", func() {
+			Expect(err).To(MatchError(ContainSubstring("is not a valid action")))
+		})
+	})
+
+	Context("no action", func() {
+		BeforeEach(func() {
+			params = `{ "name": "Holiday Season" }`
+		})
+
+		It("fails", func() {
+			Expect(err).To(MatchError(ContainSubstring("validation for 'Action' failed")))
+		})
+	})
+	Context("mistyped param", func() {
+		BeforeEach(func() {
+			params = `{ "action": "add", "name": "Holiday Season", "ends": "2023-12-27T06:00:00Z" }`
+		})
+
+		It("fails with a suggestion", func() {
+			Expect(err).To(MatchError(ContainSubstring("unknown key 'ends' in params; did you mean 'ends_at'?")))
 		})
 	})
 })
+
+// createOrigin creates a bare repository with a single commit adding the given file
+// hookedClock calls hook when asked for the time for the first time
+type hookedClock struct {
+	*timeMachine.Mock
+	once sync.Once
+	hook func()
+}
+
+func (c *hookedClock) Now() time.Time {
+	c.once.Do(c.hook)
+	return c.Mock.Now()
+}
+
+// commitToOrigin commits a new content of fileName to the origin, like someone else pushing in the meantime
+func commitToOrigin(origin, fileName, content string) error {
+	clone := origin + "-other"
+
+	repo, err := git.PlainClone(clone, false, &git.CloneOptions{URL: origin})
+
+	if err != nil {
+		return err
+	}
+
+	w, err := repo.Worktree()
+
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(path.Join(clone, fileName), []byte(content), 0o644)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Add(fileName)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Commit("Add Oktoberfest", &git.CommitOptions{
+		Author: &object.Signature{Name: "Testbild Tester", Email: "testbild.tester@example.org", When: time.Now()},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return repo.Push(&git.PushOptions{})
+}
+
+func createOrigin(origin, fileName, content string) error {
+	seed := origin + "-seed"
+
+	repo, err := git.PlainInitWithOptions(seed, &git.PlainInitOptions{
+		InitOptions: git.InitOptions{DefaultBranch: plumbing.Main},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	w, err := repo.Worktree()
+
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(path.Join(seed, fileName), []byte(content), 0o644)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Add(fileName)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Commit("Create freeze calendar", &git.CommitOptions{
+		Author: &object.Signature{Name: "Testbild Tester", Email: "testbild.tester@example.org", When: time.Now()},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	_, err = git.PlainClone(origin, true, &git.CloneOptions{URL: seed})
+
+	return err
+}
+
+func headOf(origin string) *object.Commit {
+	repo, err := git.PlainOpen(origin)
+	Expect(err).ToNot(HaveOccurred())
+
+	head, err := repo.Head()
+	Expect(err).ToNot(HaveOccurred())
+
+	commit, err := repo.CommitObject(head.Hash())
+	Expect(err).ToNot(HaveOccurred())
+
+	return commit
+}
+
+func fileAt(origin, fileName string) string {
+	file, err := headOf(origin).File(fileName)
+	Expect(err).ToNot(HaveOccurred())
+
+	content, err := file.Contents()
+	Expect(err).ToNot(HaveOccurred())
+
+	return content
+}
+
+func calendarAt(origin, fileName string) *freeze.Calendar {
+	calendar, err := freeze.LoadCalendar(strings.NewReader(fileAt(origin, fileName)))
+	Expect(err).ToNot(HaveOccurred())
+
+	return calendar
+}
@@ -0,0 +1,83 @@
+package resource
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/homeport/freeze-calendar-resource/freeze"
+)
+
+// CalendarPaths returns path and paths; both may contain globs. A calendar downloaded from URL is stored at FileName,
+// unless URL is a local directory, in which path and paths are resolved.
+func (source Source) CalendarPaths() []string {
+	if source.URL != "" && !source.isLocalDirectory() {
+		return []string{source.FileName()}
+	}
+
+	if source.Path == "" {
+		return source.Paths
+	}
+
+	return append([]string{source.Path}, source.Paths...)
+}
+
+// IsCalendar tells whether the file at the given path is one of the source's calendars
+func (source Source) IsCalendar(name string) bool {
+	for _, pattern := range source.CalendarPaths() {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LoadCalendar loads all calendars of the source from fs, including the ones they include by means of includes, and
+// merges them into one
+func (source Source) LoadCalendar(fs billy.Filesystem, includes freeze.Fetcher) (*freeze.Calendar, error) {
+	var calendars []*freeze.Calendar
+
+	for _, pattern := range source.CalendarPaths() {
+		matches, err := util.Glob(fs, pattern)
+
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve path %s: %w", pattern, err)
+		}
+
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("unable to read calendar file from path %s: no such file", pattern)
+		}
+
+		for _, match := range matches {
+			calendar, err := loadCalendarFile(fs, match)
+
+			if err != nil {
+				return nil, err
+			}
+
+			calendars = append(calendars, calendar)
+		}
+	}
+
+	return freeze.ResolveIncludes(freeze.Merge(calendars...), includes)
+}
+
+func loadCalendarFile(fs billy.Filesystem, name string) (*freeze.Calendar, error) {
+	calendarFile, err := fs.Open(name)
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to read calendar file from path %s: %w", name, err)
+	}
+
+	defer calendarFile.Close()
+
+	calendar, err := freeze.Load(name, calendarFile)
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to load calendar %s: %w", name, err)
+	}
+
+	return calendar, nil
+}
@@ -0,0 +1,105 @@
+package resource_test
+
+import (
+	"context"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/homeport/freeze-calendar-resource/freeze"
+	"github.com/homeport/freeze-calendar-resource/resource"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Source with multiple calendars", func() {
+	var (
+		err      error
+		fs       billy.Filesystem
+		source   resource.Source
+		calendar *freeze.Calendar
+	)
+
+	BeforeEach(func() {
+		fs = memfs.New()
+		source = resource.Source{
+			URI:   "git@github.com:homeport/freeze-calendar-resource",
+			Path:  "global.yaml",
+			Paths: []string{"teams/*.yaml"},
+		}
+
+		Expect(util.WriteFile(fs, "global.yaml", []byte(`
+freeze_calendar:
+  - name: Holiday Season
+    starts_at: 2023-12-01T06:00:00Z
+    ends_at: 2023-12-27T06:00:00Z
+`), 0o644)).To(Succeed())
+
+		Expect(util.WriteFile(fs, "teams/payments.yaml", []byte(`
+timezone: Europe/Berlin
+freeze_calendar:
+  - name: Year-end Closing
+    starts_at: 2023-12-29 18:00
+    ends_at: 2024-01-02 06:00
+overrides:
+  - reason: Hotfix for INC-4711
+    approver: jane.doe@example.com
+    expires_at: 2023-12-30 20:00
+`), 0o644)).To(Succeed())
+
+		Expect(util.WriteFile(fs, "teams/shop.ics", []byte("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY:Black Friday\r\nDTSTART:20231124T000000Z\r\nDTEND:20231128T000000Z\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"), 0o644)).To(Succeed())
+	})
+
+	JustBeforeEach(func() {
+		calendar, err = source.LoadCalendar(fs, resource.NewGitFetcher(context.Background(), source))
+	})
+
+	It("merges all calendars", func() {
+		Expect(err).ToNot(HaveOccurred())
+		Expect(calendar.Windows).To(HaveLen(2))
+		Expect(calendar.Overrides).To(HaveLen(1))
+	})
+
+	It("tags each window with the file it came from", func() {
+		Expect(calendar.Windows[0].Origin).To(Equal("global.yaml"))
+		Expect(calendar.Windows[1].Origin).To(Equal("teams/payments.yaml"))
+		Expect(calendar.Windows[1].String()).To(HaveSuffix("; defined in teams/payments.yaml"))
+	})
+
+	It("keeps the time zone of each calendar", func() {
+		Expect(calendar.Windows[1].Start.Location().String()).To(Equal("Europe/Berlin"))
+	})
+
+	Context("with a glob matching files of different formats", func() {
+		BeforeEach(func() {
+			source.Paths = []string{"teams/*"}
+		})
+
+		It("loads each with its own loader", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calendar.Windows).To(HaveLen(3))
+			Expect(calendar.Windows[1].Name).To(Equal("Year-end Closing"))
+			Expect(calendar.Windows[2].Name).To(Equal("Black Friday"))
+		})
+	})
+
+	Context("with a path that matches nothing", func() {
+		BeforeEach(func() {
+			source.Paths = []string{"squads/*.yaml"}
+		})
+
+		It("fails", func() {
+			Expect(err).To(MatchError(ContainSubstring("squads/*.yaml: no such file")))
+		})
+	})
+
+	DescribeTable("recognizing calendar files",
+		func(name string, expected bool) {
+			Expect(source.IsCalendar(name)).To(Equal(expected))
+		},
+		Entry("path", "global.yaml", true),
+		Entry("glob", "teams/payments.yaml", true),
+		Entry("other file", "README.md", false),
+		Entry("other directory", "teams/old/payments.yaml", false),
+	)
+})
@@ -0,0 +1,102 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+)
+
+// Fetcher gets the calendar of a source, from git, a web server or the local file system
+type Fetcher interface {
+	// Versions returns the versions not older than the given one, oldest first, as check emits them, together with the
+	// calendar files at the latest version. If the given version is unknown, only the latest version is returned.
+	Versions(ctx context.Context, since Version) ([]Version, billy.Filesystem, error)
+
+	// Fetch puts the calendar files at the given version into destination, or the latest ones if the version is empty
+	// or cannot be fetched (sources without history only have the latest). It returns the version fetched.
+	Fetch(ctx context.Context, destination string, version Version) (Version, error)
+
+	// Update replaces the fetched calendar files by the latest ones, which may still be the same
+	Update(ctx context.Context) (Version, error)
+}
+
+// Fetcher returns the fetcher for the source's uri or url. Progress of git operations is written to progress.
+func (source Source) Fetcher(progress io.Writer) (Fetcher, error) {
+	if source.URL == "" {
+		return &repositoryFetcher{source: source, progress: progress}, nil
+	}
+
+	u, err := url.Parse(source.URL)
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse url %s: %w", source.URL, err)
+	}
+
+	if len(source.Paths) > 0 && !source.isLocalDirectory() {
+		return nil, fmt.Errorf("paths are only supported with a uri, or with the file url of a directory, which ends with a slash")
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		return &httpFetcher{source: source}, nil
+	case "file":
+		if source.isLocalDirectory() && len(source.CalendarPaths()) == 0 {
+			return nil, fmt.Errorf("url %s is a directory, but neither path nor paths name the calendars within it", source.URL)
+		}
+
+		return &localFetcher{source: source, path: u.Path}, nil
+	default:
+		return nil, fmt.Errorf("unsupported scheme %s of url %s; supported are http, https and file", u.Scheme, source.URL)
+	}
+}
+
+// isLocalDirectory tells whether URL is the file url of a directory, i.e. it ends with a slash, so that the calendars
+// are read from path and paths within it
+func (source Source) isLocalDirectory() bool {
+	u, err := url.Parse(source.URL)
+
+	return err == nil && u.Scheme == "file" && strings.HasSuffix(u.Path, "/")
+}
+
+// store writes the calendar files downloaded from (or read from) URL into destination, by their name
+func (source Source) store(destination string, files map[string][]byte) error {
+	for name, content := range files {
+		file := filepath.Join(destination, name)
+		err := os.MkdirAll(filepath.Dir(file), 0o755)
+
+		if err != nil {
+			return fmt.Errorf("unable to create directory for %s: %w", file, err)
+		}
+
+		err = os.WriteFile(file, content, 0o644)
+
+		if err != nil {
+			return fmt.Errorf("unable to write calendar to %s: %w", file, err)
+		}
+	}
+
+	return nil
+}
+
+// calendarFilesystem returns an in-memory filesystem with the calendar files downloaded from (or read from) URL
+func (source Source) calendarFilesystem(files map[string][]byte) (billy.Filesystem, error) {
+	fs := memfs.New()
+
+	for name, content := range files {
+		err := util.WriteFile(fs, name, content, 0o644)
+
+		if err != nil {
+			return nil, fmt.Errorf("unable to store calendar: %w", err)
+		}
+	}
+
+	return fs, nil
+}
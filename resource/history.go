@@ -0,0 +1,239 @@
+package resource
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// calendarHistory finds the commits that changed the calendars of source
+type calendarHistory struct {
+	repo   *git.Repository
+	source Source
+}
+
+// since returns the commits that are reachable from head, but not from since, and changed a calendar, oldest first.
+// The second return value tells whether since is reachable from head at all.
+func (h calendarHistory) since(head, since *object.Commit) ([]*object.Commit, bool, error) {
+	exclude := map[plumbing.Hash]bool{}
+
+	if since != nil {
+		ancestors, _, err := h.walk(since, nil)
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		for _, ancestor := range ancestors {
+			exclude[ancestor.Hash] = true
+		}
+	}
+
+	commits, excluded, err := h.walk(head, exclude)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	var changes []*object.Commit
+	var base *object.Tree
+
+	if since != nil {
+		base, err = since.Tree()
+
+		if err != nil {
+			return nil, false, fmt.Errorf("unable to get tree of %s: %w", since.Hash, err)
+		}
+	}
+
+	for _, commit := range chronological(commits) {
+		changed, err := h.changesCalendar(commit, base)
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		if changed {
+			changes = append(changes, commit)
+		}
+	}
+
+	return changes, since != nil && (head.Hash == since.Hash || excluded[since.Hash]), nil
+}
+
+// walk returns all commits reachable from head without passing any in exclude, and the excluded ones it ran into.
+// Parents missing from a shallow clone are skipped.
+func (h calendarHistory) walk(head *object.Commit, exclude map[plumbing.Hash]bool) ([]*object.Commit, map[plumbing.Hash]bool, error) {
+	excluded := map[plumbing.Hash]bool{}
+
+	if exclude[head.Hash] {
+		excluded[head.Hash] = true
+		return nil, excluded, nil
+	}
+
+	visited := map[plumbing.Hash]bool{head.Hash: true}
+	commits := []*object.Commit{head}
+
+	for i := 0; i < len(commits); i++ {
+		parents, err := h.parents(commits[i])
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, parent := range parents {
+			switch {
+			case exclude[parent.Hash]:
+				excluded[parent.Hash] = true
+			case !visited[parent.Hash]:
+				visited[parent.Hash] = true
+				commits = append(commits, parent)
+			}
+		}
+	}
+
+	return commits, excluded, nil
+}
+
+// parents returns the parents of commit that are present in the repository
+func (h calendarHistory) parents(commit *object.Commit) ([]*object.Commit, error) {
+	var parents []*object.Commit
+
+	for _, hash := range commit.ParentHashes {
+		parent, err := h.repo.CommitObject(hash)
+
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			continue // beyond the boundary of a shallow clone
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("unable to get parent %s of %s: %w", hash, commit.Hash, err)
+		}
+
+		parents = append(parents, parent)
+	}
+
+	return parents, nil
+}
+
+// changesCalendar tells whether commit changed a calendar. Like in git log, a merge only counts if it differs from
+// each of its parents, e.g. because of a conflict resolution; otherwise, the change is attributed to the merged commits.
+// A commit at the boundary of a shallow clone, whose parents are missing, is compared with base instead (nil for none).
+func (h calendarHistory) changesCalendar(commit *object.Commit, base *object.Tree) (bool, error) {
+	tree, err := commit.Tree()
+
+	if err != nil {
+		return false, fmt.Errorf("unable to get tree of %s: %w", commit.Hash, err)
+	}
+
+	parents, err := h.parents(commit)
+
+	if err != nil {
+		return false, err
+	}
+
+	if len(parents) == 0 && len(commit.ParentHashes) > 0 {
+		return h.differ(base, tree)
+	}
+
+	if len(parents) == 0 {
+		return h.differ(nil, tree)
+	}
+
+	for _, parent := range parents {
+		parentTree, err := parent.Tree()
+
+		if err != nil {
+			return false, fmt.Errorf("unable to get tree of %s: %w", parent.Hash, err)
+		}
+
+		differ, err := h.differ(parentTree, tree)
+
+		if err != nil || !differ {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func (h calendarHistory) differ(a, b *object.Tree) (bool, error) {
+	changes, err := object.DiffTree(a, b)
+
+	if err != nil {
+		return false, fmt.Errorf("unable to diff trees: %w", err)
+	}
+
+	for _, change := range changes {
+		if h.source.IsCalendar(change.From.Name) || h.source.IsCalendar(change.To.Name) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// chronological orders commits by committer time, oldest first, but always puts parents before their children, even
+// if clocks were skewed
+func chronological(commits []*object.Commit) []*object.Commit {
+	included := map[plumbing.Hash]bool{}
+
+	for _, commit := range commits {
+		included[commit.Hash] = true
+	}
+
+	// number of parents not yet ordered, and children of each commit
+	pending := map[plumbing.Hash]int{}
+	children := map[plumbing.Hash][]*object.Commit{}
+	ready := &byCommitterTime{}
+
+	for _, commit := range commits {
+		for _, parent := range commit.ParentHashes {
+			if included[parent] {
+				pending[commit.Hash]++
+				children[parent] = append(children[parent], commit)
+			}
+		}
+
+		if pending[commit.Hash] == 0 {
+			heap.Push(ready, commit)
+		}
+	}
+
+	ordered := make([]*object.Commit, 0, len(commits))
+
+	for ready.Len() > 0 {
+		commit := heap.Pop(ready).(*object.Commit)
+		ordered = append(ordered, commit)
+
+		for _, child := range children[commit.Hash] {
+			pending[child.Hash]--
+
+			if pending[child.Hash] == 0 {
+				heap.Push(ready, child)
+			}
+		}
+	}
+
+	return ordered
+}
+
+// byCommitterTime is a min-heap of commits
+type byCommitterTime []*object.Commit
+
+func (b byCommitterTime) Len() int           { return len(b) }
+func (b byCommitterTime) Less(i, j int) bool { return b[i].Committer.When.Before(b[j].Committer.When) }
+func (b byCommitterTime) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b *byCommitterTime) Push(x any)        { *b = append(*b, x.(*object.Commit)) }
+
+func (b *byCommitterTime) Pop() any {
+	old := *b
+	commit := old[len(old)-1]
+	*b = old[:len(old)-1]
+
+	return commit
+}
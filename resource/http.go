@@ -0,0 +1,183 @@
+package resource
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/go-git/go-billy/v5"
+)
+
+// Document is a calendar downloaded from Source.URL
+type Document struct {
+	Content     []byte
+	ETag        string // as sent by the server; may be empty
+	NotModified bool   // the server confirmed that the calendar still has the ETag asked for; Content is empty
+}
+
+// Version identifies the document by its ETag, or by a hash of its content if the server sends none
+func (d Document) Version() Version {
+	if d.ETag != "" {
+		return Version{ETag: d.ETag}
+	}
+
+	sum := sha256.Sum256(d.Content)
+
+	return Version{ETag: "sha256:" + hex.EncodeToString(sum[:])}
+}
+
+// httpFetcher downloads the calendar from the source's URL. As there is no history, the current version is the only one.
+type httpFetcher struct {
+	source      Source
+	destination string
+	etag        string // as sent by the server with the last download
+	version     Version
+}
+
+func (h *httpFetcher) Versions(ctx context.Context, since Version) ([]Version, billy.Filesystem, error) {
+	document, err := h.source.Download(ctx, "")
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fs, err := h.source.calendarFilesystem(map[string][]byte{h.source.FileName(): document.Content})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return []Version{document.Version()}, fs, nil
+}
+
+func (h *httpFetcher) Fetch(ctx context.Context, destination string, version Version) (Version, error) {
+	h.destination = destination
+
+	return h.Update(ctx)
+}
+
+func (h *httpFetcher) Update(ctx context.Context) (Version, error) {
+	document, err := h.source.Download(ctx, h.etag)
+
+	if err != nil {
+		return Version{}, err
+	}
+
+	if document.NotModified {
+		return h.version, nil
+	}
+
+	err = h.source.store(h.destination, map[string][]byte{h.source.FileName(): document.Content})
+
+	if err != nil {
+		return Version{}, err
+	}
+
+	h.etag = document.ETag
+	h.version = document.Version()
+
+	return h.version, nil
+}
+
+// FileName returns the name a calendar downloaded from URL is stored as: path if given, or the last element of the URL's path
+func (source Source) FileName() string {
+	if source.Path != "" {
+		return source.Path
+	}
+
+	u, err := url.Parse(source.URL)
+
+	if err != nil || path.Base(u.Path) == "/" || path.Base(u.Path) == "." {
+		return "calendar.yaml"
+	}
+
+	return path.Base(u.Path)
+}
+
+// Download fetches the calendar from URL. If etag is not empty, it is sent as If-None-Match, so that an unchanged
+// calendar is not transferred again.
+func (source Source) Download(ctx context.Context, etag string) (Document, error) {
+	client, err := source.HTTPClient()
+
+	if err != nil {
+		return Document{}, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, source.URL, nil)
+
+	if err != nil {
+		return Document{}, fmt.Errorf("unable to build request: %w", err)
+	}
+
+	for name, value := range source.Headers {
+		request.Header.Set(name, value)
+	}
+
+	if source.Token != "" {
+		if source.Username != "" || source.Password != "" {
+			return Document{}, errors.New("both token and {username, password} are set, but only one of these is allowed")
+		}
+
+		request.Header.Set("Authorization", "Bearer "+source.Token)
+	} else if source.Username != "" && source.Password != "" {
+		request.SetBasicAuth(source.Username, source.Password)
+	}
+
+	if etag != "" {
+		request.Header.Set("If-None-Match", etag)
+	}
+
+	response, err := client.Do(request)
+
+	if err != nil {
+		return Document{}, fmt.Errorf("unable to download %s: %w", source.URL, err)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotModified && etag != "" {
+		return Document{ETag: etag, NotModified: true}, nil
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return Document{}, fmt.Errorf("unable to download %s: %s", source.URL, response.Status)
+	}
+
+	content, err := io.ReadAll(response.Body)
+
+	if err != nil {
+		return Document{}, fmt.Errorf("unable to read %s: %w", source.URL, err)
+	}
+
+	return Document{Content: content, ETag: response.Header.Get("ETag")}, nil
+}
+
+// HTTPClient returns a client that trusts CACerts in addition to the system's certificate authorities
+func (source Source) HTTPClient() (*http.Client, error) {
+	if source.CACerts == "" {
+		return http.DefaultClient, nil
+	}
+
+	pool, err := x509.SystemCertPool()
+
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	if !pool.AppendCertsFromPEM([]byte(source.CACerts)) {
+		return nil, errors.New("ca_certs does not contain any PEM-encoded certificate")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+
+	return &http.Client{Transport: transport}, nil
+}
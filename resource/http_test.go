@@ -0,0 +1,182 @@
+package resource_test
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/homeport/freeze-calendar-resource/resource"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Download", func() {
+	const calendar = "freeze_calendar: []\n"
+
+	var (
+		err      error
+		server   *httptest.Server
+		received *http.Request
+		etag     string
+		source   resource.Source
+		sent     string
+		document resource.Document
+	)
+
+	BeforeEach(func() {
+		etag = `"v1"`
+		sent = ""
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r
+
+			if etag != "" {
+				if r.Header.Get("If-None-Match") == etag {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+
+				w.Header().Set("ETag", etag)
+			}
+
+			_, _ = w.Write([]byte(calendar))
+		}))
+		DeferCleanup(server.Close)
+
+		source = resource.Source{URL: server.URL + "/cab/freeze-calendar.yaml"}
+	})
+
+	JustBeforeEach(func(ctx SpecContext) {
+		document, err = source.Download(ctx, sent)
+	})
+
+	It("downloads the calendar", func() {
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(string(document.Content)).To(Equal(calendar))
+	})
+
+	It("uses the ETag as version", func() {
+		Expect(document.Version()).To(Equal(resource.Version{ETag: `"v1"`}))
+	})
+
+	It("stores the calendar under the name from the URL", func() {
+		Expect(source.FileName()).To(Equal("freeze-calendar.yaml"))
+		Expect(source.CalendarPaths()).To(ConsistOf("freeze-calendar.yaml"))
+	})
+
+	Context("with the current ETag", func() {
+		BeforeEach(func() {
+			sent = `"v1"`
+		})
+
+		It("is not modified", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(document.NotModified).To(BeTrue())
+			Expect(document.Version()).To(Equal(resource.Version{ETag: `"v1"`}))
+		})
+	})
+
+	Context("with an outdated ETag", func() {
+		BeforeEach(func() {
+			sent = `"v0"`
+		})
+
+		It("downloads the calendar", func() {
+			Expect(document.NotModified).To(BeFalse())
+			Expect(string(document.Content)).To(Equal(calendar))
+		})
+	})
+
+	Context("from a server without ETags", func() {
+		BeforeEach(func() {
+			etag = ""
+		})
+
+		It("uses a hash of the content as version", func() {
+			Expect(document.Version()).To(Equal(resource.Version{ETag: "sha256:f3f8f38fdd1615dcdf38b575d2acc0dd9a1517404981c35a89626ea6c052742e"}))
+		})
+	})
+
+	Context("with a token and headers", func() {
+		BeforeEach(func() {
+			source.Token = "s3cr3t"
+			source.Headers = map[string]string{"X-Team": "release"}
+		})
+
+		It("sends them", func() {
+			Expect(received.Header.Get("Authorization")).To(Equal("Bearer s3cr3t"))
+			Expect(received.Header.Get("X-Team")).To(Equal("release"))
+		})
+	})
+
+	Context("with username and password", func() {
+		BeforeEach(func() {
+			source.Username = "release"
+			source.Password = "s3cr3t"
+		})
+
+		It("uses basic auth", func() {
+			username, password, ok := received.BasicAuth()
+			Expect(ok).To(BeTrue())
+			Expect(username).To(Equal("release"))
+			Expect(password).To(Equal("s3cr3t"))
+		})
+	})
+
+	Context("with both a token and username and password", func() {
+		BeforeEach(func() {
+			source.Token = "s3cr3t"
+			source.Username = "release"
+			source.Password = "s3cr3t"
+		})
+
+		It("fails", func() {
+			Expect(err).To(MatchError(ContainSubstring("only one of these is allowed")))
+		})
+	})
+
+	Context("of a calendar that does not exist", func() {
+		BeforeEach(func() {
+			server.Config.Handler = http.NotFoundHandler()
+		})
+
+		It("fails", func() {
+			Expect(err).To(MatchError(ContainSubstring("404 Not Found")))
+		})
+	})
+
+	Context("over TLS", func() {
+		var tlsServer *httptest.Server
+
+		BeforeEach(func() {
+			tlsServer = httptest.NewTLSServer(server.Config.Handler)
+			DeferCleanup(tlsServer.Close)
+			source.URL = tlsServer.URL + "/freeze-calendar.yaml"
+		})
+
+		It("rejects an unknown certificate authority", func() {
+			Expect(err).To(MatchError(ContainSubstring("certificate")))
+		})
+
+		Context("with the CA bundle", func() {
+			BeforeEach(func() {
+				source.CACerts = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsServer.Certificate().Raw}))
+			})
+
+			It("downloads the calendar", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(string(document.Content)).To(Equal(calendar))
+			})
+		})
+
+		Context("with a CA bundle that is not PEM", func() {
+			BeforeEach(func() {
+				source.CACerts = "not a certificate"
+			})
+
+			It("fails", func() {
+				Expect(err).To(MatchError(ContainSubstring("ca_certs does not contain any PEM-encoded certificate")))
+			})
+		})
+	})
+})
@@ -0,0 +1,113 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/homeport/freeze-calendar-resource/freeze"
+)
+
+var commitHash = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// GitFetcher fetches included calendars from their git repositories. Each repository is cloned (into memory) only
+// once per fetcher.
+type GitFetcher struct {
+	ctx      context.Context
+	source   Source
+	worktree map[string]billy.Filesystem
+}
+
+func NewGitFetcher(ctx context.Context, source Source) *GitFetcher {
+	return &GitFetcher{
+		ctx:      ctx,
+		source:   source,
+		worktree: map[string]billy.Filesystem{},
+	}
+}
+
+func (f *GitFetcher) Fetch(include freeze.Include) (io.ReadCloser, error) {
+	key := include.URI + "@" + include.Ref
+	fs, found := f.worktree[key]
+
+	if !found {
+		var err error
+		fs, err = f.clone(include)
+
+		if err != nil {
+			return nil, err
+		}
+
+		f.worktree[key] = fs
+	}
+
+	return fs.Open(include.Path)
+}
+
+func (f *GitFetcher) clone(include freeze.Include) (billy.Filesystem, error) {
+	auth, err := f.source.IncludeAuth(include.URI)
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to build authenticator for %s: %w", include.URI, err)
+	}
+
+	options := &git.CloneOptions{
+		URL:  include.URI,
+		Auth: auth,
+	}
+
+	// a commit needs the history to be checked out; branches and tags do not
+	if !commitHash.MatchString(include.Ref) {
+		options.ReferenceName = plumbing.ReferenceName(include.Ref)
+		options.SingleBranch = true
+		options.Depth = 1
+	}
+
+	fs := memfs.New()
+	repo, err := git.CloneContext(f.ctx, memory.NewStorage(), fs, options)
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to clone %s: %w", include.URI, err)
+	}
+
+	if commitHash.MatchString(include.Ref) {
+		worktree, err := repo.Worktree()
+
+		if err != nil {
+			return nil, fmt.Errorf("unable to get worktree: %w", err)
+		}
+
+		err = worktree.Checkout(&git.CheckoutOptions{Hash: plumbing.NewHash(include.Ref)})
+
+		if err != nil {
+			return nil, fmt.Errorf("unable to checkout %s: %w", include.Ref, err)
+		}
+	}
+
+	return fs, nil
+}
+
+// IncludeAuth returns the authenticator for fetching an included calendar from uri: the one of the matching
+// include_credentials, or the source's own.
+func (source Source) IncludeAuth(uri string) (transport.AuthMethod, error) {
+	for _, credentials := range source.IncludeCredentials {
+		if credentials.URI == uri {
+			return Source{
+				URI:        credentials.URI,
+				PrivateKey: credentials.PrivateKey,
+				KnownHosts: credentials.KnownHosts,
+				Username:   credentials.Username,
+				Password:   credentials.Password,
+			}.Auth()
+		}
+	}
+
+	return source.Auth()
+}
@@ -0,0 +1,108 @@
+package resource_test
+
+import (
+	"context"
+	"io"
+	"path"
+	"time"
+
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/homeport/freeze-calendar-resource/freeze"
+	"github.com/homeport/freeze-calendar-resource/resource"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GitFetcher", func() {
+	var (
+		err     error
+		uri     string
+		first   plumbing.Hash
+		include freeze.Include
+		content string
+	)
+
+	BeforeEach(func() {
+		uri = path.Join(GinkgoT().TempDir(), "corporate")
+
+		repo, err := git.PlainInitWithOptions(uri, &git.PlainInitOptions{
+			InitOptions: git.InitOptions{DefaultBranch: plumbing.Main},
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		worktree, err := repo.Worktree()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		commit := func(content string) plumbing.Hash {
+			Expect(util.WriteFile(worktree.Filesystem, "corporate.yaml", []byte(content), 0o644)).To(Succeed())
+			_, err := worktree.Add("corporate.yaml")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			hash, err := worktree.Commit(content, &git.CommitOptions{
+				Author: &object.Signature{Name: "Testbild Tester", Email: "testbild.tester@example.org", When: time.Now()},
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			return hash
+		}
+
+		first = commit("first")
+		_, err = repo.CreateTag("v1", commit("tagged"), nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		commit("latest")
+
+		include = freeze.Include{URI: uri, Path: "corporate.yaml"}
+	})
+
+	JustBeforeEach(func() {
+		var reader io.ReadCloser
+		reader, err = resource.NewGitFetcher(context.Background(), resource.Source{}).Fetch(include)
+
+		if err == nil {
+			defer reader.Close()
+			contents, readErr := io.ReadAll(reader)
+			Expect(readErr).ShouldNot(HaveOccurred())
+			content = string(contents)
+		}
+	})
+
+	It("fetches the default branch", func() {
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(content).To(Equal("latest"))
+	})
+
+	Context("at a tag", func() {
+		BeforeEach(func() {
+			include.Ref = "refs/tags/v1"
+		})
+
+		It("fetches the tagged version", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(content).To(Equal("tagged"))
+		})
+	})
+
+	Context("at a commit", func() {
+		BeforeEach(func() {
+			include.Ref = first.String()
+		})
+
+		It("fetches that commit", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(content).To(Equal("first"))
+		})
+	})
+
+	Context("of a file that does not exist", func() {
+		BeforeEach(func() {
+			include.Path = "nope.yaml"
+		})
+
+		It("fails", func() {
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,166 @@
+package resource
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-billy/v5/util"
+)
+
+// localFetcher reads the calendar from a file, e.g. on a mounted volume, or the calendars at path and paths from a
+// directory. Its version is made of the latest modification time and a hash of the content; as there is no history,
+// the current version is the only one.
+type localFetcher struct {
+	source      Source
+	path        string
+	destination string
+	version     Version
+	stored      []string // names of the files in destination
+}
+
+func (l *localFetcher) Versions(ctx context.Context, since Version) ([]Version, billy.Filesystem, error) {
+	files, version, err := l.read()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fs, err := l.source.calendarFilesystem(files)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return []Version{version}, fs, nil
+}
+
+func (l *localFetcher) Fetch(ctx context.Context, destination string, version Version) (Version, error) {
+	l.destination = destination
+
+	return l.Update(ctx)
+}
+
+func (l *localFetcher) Update(ctx context.Context) (Version, error) {
+	files, version, err := l.read()
+
+	if err != nil {
+		return Version{}, err
+	}
+
+	if version == l.version {
+		return l.version, nil
+	}
+
+	// calendars that are gone from the directory must not be loaded from the destination any longer
+	for _, name := range l.stored {
+		if _, found := files[name]; !found {
+			err = os.Remove(filepath.Join(l.destination, name))
+
+			if err != nil && !os.IsNotExist(err) {
+				return Version{}, fmt.Errorf("unable to remove calendar %s: %w", name, err)
+			}
+		}
+	}
+
+	err = l.source.store(l.destination, files)
+
+	if err != nil {
+		return Version{}, err
+	}
+
+	l.stored = slices.Collect(maps.Keys(files))
+	l.version = version
+
+	return l.version, nil
+}
+
+// read returns the calendar files by the name they are stored as, and their version
+func (l *localFetcher) read() (map[string][]byte, Version, error) {
+	info, err := os.Stat(l.path)
+
+	if err != nil {
+		return nil, Version{}, fmt.Errorf("unable to read calendar file from path %s: %w", l.path, err)
+	}
+
+	if l.source.isLocalDirectory() {
+		if !info.IsDir() {
+			return nil, Version{}, fmt.Errorf("unable to read calendars from directory %s: not a directory", l.path)
+		}
+
+		return l.readDirectory()
+	}
+
+	if info.IsDir() {
+		return nil, Version{}, fmt.Errorf("unable to read calendar file from path %s: is a directory; end the url with a slash to read the calendars at path and paths within it", l.path)
+	}
+
+	content, err := os.ReadFile(l.path)
+
+	if err != nil {
+		return nil, Version{}, fmt.Errorf("unable to read calendar file from path %s: %w", l.path, err)
+	}
+
+	sum := sha256.Sum256(content)
+
+	return map[string][]byte{l.source.FileName(): content}, Version{
+		Hash:       "sha256:" + hex.EncodeToString(sum[:]),
+		ModifiedAt: info.ModTime().UTC().Format(time.RFC3339Nano),
+	}, nil
+}
+
+// readDirectory reads the files matching path and paths within the directory. The hash covers their names, too, so
+// that a renamed or removed calendar makes a new version.
+func (l *localFetcher) readDirectory() (map[string][]byte, Version, error) {
+	fs := osfs.New(l.path)
+	files := map[string][]byte{}
+	var modifiedAt time.Time
+
+	for _, pattern := range l.source.CalendarPaths() {
+		matches, err := util.Glob(fs, pattern)
+
+		if err != nil {
+			return nil, Version{}, fmt.Errorf("unable to resolve path %s: %w", pattern, err)
+		}
+
+		for _, match := range matches {
+			info, err := fs.Stat(match)
+
+			if err != nil {
+				return nil, Version{}, fmt.Errorf("unable to read calendar file from path %s: %w", match, err)
+			}
+
+			content, err := util.ReadFile(fs, match)
+
+			if err != nil {
+				return nil, Version{}, fmt.Errorf("unable to read calendar file from path %s: %w", match, err)
+			}
+
+			files[filepath.ToSlash(match)] = content
+
+			if info.ModTime().After(modifiedAt) {
+				modifiedAt = info.ModTime()
+			}
+		}
+	}
+
+	hash := sha256.New()
+
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		fmt.Fprintf(hash, "%s\x00%d\x00", name, len(files[name]))
+		hash.Write(files[name])
+	}
+
+	return files, Version{
+		Hash:       "sha256:" + hex.EncodeToString(hash.Sum(nil)),
+		ModifiedAt: modifiedAt.UTC().Format(time.RFC3339Nano),
+	}, nil
+}
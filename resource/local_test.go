@@ -0,0 +1,200 @@
+package resource_test
+
+import (
+	"os"
+	"path"
+	"time"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/homeport/freeze-calendar-resource/resource"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Local fetcher", func() {
+	var (
+		err         error
+		calendar    string
+		destination string
+		fetcher     resource.Fetcher
+	)
+
+	BeforeEach(func() {
+		dir := GinkgoT().TempDir()
+		calendar = path.Join(dir, "volume", "freeze-calendar.yaml")
+		destination = path.Join(dir, "destination")
+
+		Expect(os.MkdirAll(path.Dir(calendar), 0o755)).To(Succeed())
+		Expect(os.WriteFile(calendar, []byte("freeze_calendar: []\n"), 0o644)).To(Succeed())
+		Expect(os.Chtimes(calendar, time.Time{}, time.Date(2023, time.October, 11, 12, 0, 0, 0, time.UTC))).To(Succeed())
+
+		fetcher, err = resource.Source{URL: "file://" + calendar}.Fetcher(GinkgoWriter)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	Describe("versions", func() {
+		var (
+			versions []resource.Version
+			fs       billy.Filesystem
+		)
+
+		JustBeforeEach(func(ctx SpecContext) {
+			versions, fs, err = fetcher.Versions(ctx, resource.Version{})
+		})
+
+		It("has the modification time and hash as the only version", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(versions).To(Equal([]resource.Version{{
+				Hash:       "sha256:f3f8f38fdd1615dcdf38b575d2acc0dd9a1517404981c35a89626ea6c052742e",
+				ModifiedAt: "2023-10-11T12:00:00Z",
+			}}))
+		})
+
+		It("has the calendar", func() {
+			Expect(util.ReadFile(fs, "freeze-calendar.yaml")).To(BeEquivalentTo("freeze_calendar: []\n"))
+		})
+
+		Context("of a file that does not exist", func() {
+			BeforeEach(func() {
+				Expect(os.Remove(calendar)).To(Succeed())
+			})
+
+			It("fails", func() {
+				Expect(err).To(MatchError(ContainSubstring("unable to read calendar file from path")))
+			})
+		})
+	})
+
+	Describe("fetching", func() {
+		var version resource.Version
+
+		JustBeforeEach(func(ctx SpecContext) {
+			version, err = fetcher.Fetch(ctx, destination, resource.Version{})
+		})
+
+		It("copies the calendar to the destination", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(os.ReadFile(path.Join(destination, "freeze-calendar.yaml"))).To(BeEquivalentTo("freeze_calendar: []\n"))
+		})
+
+		It("updates the copy once the calendar has changed", func(ctx SpecContext) {
+			Expect(os.WriteFile(calendar, []byte("freeze_calendar: [] # changed\n"), 0o644)).To(Succeed())
+
+			updated, err := fetcher.Update(ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(updated).ToNot(Equal(version))
+			Expect(os.ReadFile(path.Join(destination, "freeze-calendar.yaml"))).To(BeEquivalentTo("freeze_calendar: [] # changed\n"))
+		})
+
+		It("keeps the version while the calendar is unchanged", func(ctx SpecContext) {
+			Expect(fetcher.Update(ctx)).To(Equal(version))
+		})
+	})
+})
+
+var _ = Describe("Local fetcher of a directory", func() {
+	var (
+		err         error
+		volume      string
+		destination string
+		fetcher     resource.Fetcher
+	)
+
+	BeforeEach(func() {
+		dir := GinkgoT().TempDir()
+		volume = path.Join(dir, "volume")
+		destination = path.Join(dir, "destination")
+
+		Expect(os.MkdirAll(path.Join(volume, "teams"), 0o755)).To(Succeed())
+		Expect(os.WriteFile(path.Join(volume, "global.yaml"), []byte("freeze_calendar: []\n"), 0o644)).To(Succeed())
+		Expect(os.WriteFile(path.Join(volume, "teams", "a.yaml"), []byte("freeze_calendar: [] # a\n"), 0o644)).To(Succeed())
+		Expect(os.WriteFile(path.Join(volume, "teams", "b.yaml"), []byte("freeze_calendar: [] # b\n"), 0o644)).To(Succeed())
+		Expect(os.WriteFile(path.Join(volume, "README.md"), []byte("not a calendar\n"), 0o644)).To(Succeed())
+
+		fetcher, err = resource.Source{
+			URL:   "file://" + volume + "/",
+			Path:  "global.yaml",
+			Paths: []string{"teams/*.yaml"},
+		}.Fetcher(GinkgoWriter)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	Describe("versions", func() {
+		var (
+			versions []resource.Version
+			fs       billy.Filesystem
+		)
+
+		JustBeforeEach(func(ctx SpecContext) {
+			versions, fs, err = fetcher.Versions(ctx, resource.Version{})
+		})
+
+		It("has a single version", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(versions).To(HaveLen(1))
+			Expect(versions[0].Hash).To(HavePrefix("sha256:"))
+		})
+
+		It("has the calendars matching path and paths", func() {
+			Expect(util.ReadFile(fs, "global.yaml")).To(BeEquivalentTo("freeze_calendar: []\n"))
+			Expect(util.ReadFile(fs, "teams/a.yaml")).To(BeEquivalentTo("freeze_calendar: [] # a\n"))
+			Expect(util.ReadFile(fs, "teams/b.yaml")).To(BeEquivalentTo("freeze_calendar: [] # b\n"))
+			_, err := fs.Stat("README.md")
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("has a new version once a calendar is removed", func(ctx SpecContext) {
+			Expect(os.Remove(path.Join(volume, "teams", "b.yaml"))).To(Succeed())
+
+			updated, _, err := fetcher.Versions(ctx, resource.Version{})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(updated).ToNot(Equal(versions))
+		})
+	})
+
+	Describe("fetching", func() {
+		JustBeforeEach(func(ctx SpecContext) {
+			_, err = fetcher.Fetch(ctx, destination, resource.Version{})
+		})
+
+		It("copies the calendars to the destination", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(os.ReadFile(path.Join(destination, "global.yaml"))).To(BeEquivalentTo("freeze_calendar: []\n"))
+			Expect(os.ReadFile(path.Join(destination, "teams", "a.yaml"))).To(BeEquivalentTo("freeze_calendar: [] # a\n"))
+		})
+
+		It("removes calendars from the destination once they are gone", func(ctx SpecContext) {
+			Expect(os.Remove(path.Join(volume, "teams", "b.yaml"))).To(Succeed())
+
+			_, err := fetcher.Update(ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(path.Join(destination, "teams", "b.yaml")).ToNot(BeAnExistingFile())
+		})
+	})
+
+	It("requires the url of a directory to end with a slash", func(ctx SpecContext) {
+		fetcher, err := resource.Source{URL: "file://" + volume, Path: "global.yaml"}.Fetcher(GinkgoWriter)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, _, err = fetcher.Versions(ctx, resource.Version{})
+		Expect(err).To(MatchError(ContainSubstring("end the url with a slash")))
+	})
+
+	It("requires path or paths", func() {
+		_, err := resource.Source{URL: "file://" + volume + "/"}.Fetcher(GinkgoWriter)
+		Expect(err).To(MatchError(ContainSubstring("neither path nor paths")))
+	})
+})
+
+var _ = Describe("Fetcher", func() {
+	It("rejects paths with the url of a file", func() {
+		_, err := resource.Source{URL: "https://cab.example.com/freeze-calendar.yaml", Paths: []string{"*.yaml"}}.Fetcher(GinkgoWriter)
+		Expect(err).To(MatchError(ContainSubstring("paths are only supported")))
+	})
+
+	It("rejects unsupported schemes", func() {
+		_, err := resource.Source{URL: "ftp://cab.example.com/freeze-calendar.yaml"}.Fetcher(GinkgoWriter)
+		Expect(err).To(MatchError(ContainSubstring("unsupported scheme ftp")))
+	})
+})
@@ -1,8 +1,11 @@
 package resource
 
 import (
+	"cmp"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
@@ -10,6 +13,8 @@ import (
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/homeport/freeze-calendar-resource/keys"
+	"github.com/homeport/freeze-calendar-resource/lgr"
 	"github.com/orsinium-labs/enum"
 )
 
@@ -17,12 +22,32 @@ type Request struct {
 	Source Source `json:"source" validate:"required"`
 }
 
+// DecodeRequest decodes the JSON request read from reader into request, a pointer. Keys that request does not know,
+// e.g. a mistyped param, are an error.
+func DecodeRequest(reader io.Reader, request any) error {
+	data, err := io.ReadAll(reader)
+
+	if err != nil {
+		return err
+	}
+
+	if unknown := keys.CheckJSON(data, request); len(unknown) > 0 {
+		return errors.Join(unknown...)
+	}
+
+	return json.Unmarshal(data, request)
+}
+
 type Params struct {
-	Mode          Mode     `json:"mode" validate:"required"`
-	Scope         []string `json:"scope"`
-	Runway        Duration `json:"runway"`
-	RetryInterval Duration `json:"retry_interval"`
-	Verbose       bool     `json:"verbose"`
+	Mode           Mode       `json:"mode" validate:"required"`
+	Scope          []string   `json:"scope"`
+	Runway         Duration   `json:"runway"`
+	RetryInterval  Duration   `json:"retry_interval"`
+	MaxWait        Duration   `json:"max_wait"`
+	OnTimeout      Outcome    `json:"on_timeout"`
+	MaxFetchErrors int        `json:"max_fetch_errors" validate:"gte=0"`
+	Verbose        bool       `json:"verbose"`
+	LogFormat      lgr.Format `json:"log_format"`
 }
 
 type Mode enum.Member[string]
@@ -30,7 +55,8 @@ type Mode enum.Member[string]
 var (
 	Fuse = Mode{"fuse"}
 	Gate = Mode{"gate"}
-	Modi = enum.New(Fuse, Gate)
+	Warn = Mode{"warn"}
+	Modi = enum.New(Fuse, Gate, Warn)
 )
 
 func (m *Mode) UnmarshalJSON(b []byte) error {
@@ -51,8 +77,50 @@ func (m *Mode) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// Outcome determines whether a gate that ran out of MaxWait fails or passes
+type Outcome enum.Member[string]
+
+var (
+	Fail     = Outcome{"fail"}
+	Pass     = Outcome{"pass"}
+	Outcomes = enum.New(Fail, Pass)
+)
+
+func (o *Outcome) UnmarshalJSON(b []byte) error {
+	rawOutcome := string(b)
+	unquoted, err := strconv.Unquote(rawOutcome)
+
+	if err != nil {
+		return fmt.Errorf("unable to unquote raw outcome '%s': %w", rawOutcome, err)
+	}
+
+	parsed := Outcomes.Parse(unquoted)
+
+	if parsed == nil {
+		return fmt.Errorf("%s is not a valid outcome, valid ones are %s", string(b), Outcomes.String())
+	}
+
+	*o = *parsed
+	return nil
+}
+
 type Version struct {
-	SHA string `json:"sha"`
+	SHA        string `json:"sha,omitempty"`
+	ETag       string `json:"etag,omitempty"`        // only with an http(s) URL; the ETag of the calendar, or sha256:<hash of its content> if the server sends none
+	Hash       string `json:"hash,omitempty"`        // only with a file URL; sha256:<hash of the calendar's content>
+	ModifiedAt string `json:"modified_at,omitempty"` // only with a file URL; RFC 3339 modification time of the calendar
+	Boundary   string `json:"boundary,omitempty"`    // RFC 3339 time of the last window boundary; only with Source.Boundaries
+}
+
+// String returns the commit SHA, or the ETag or hash for sources other than git
+func (v Version) String() string {
+	return cmp.Or(v.SHA, v.ETag, v.Hash)
+}
+
+// Same tells whether both versions are of the same calendar, i.e. they differ in their boundary at most
+func (v Version) Same(other Version) bool {
+	v.Boundary, other.Boundary = "", ""
+	return v == other
 }
 
 type NameValuePair struct {
@@ -61,13 +129,47 @@ type NameValuePair struct {
 }
 
 type Source struct {
-	URI        string `json:"uri" validate:"required"` // the git resource calls it uri, so we do it, too
+	URI        string   `json:"uri" validate:"required_without=URL,excluded_with=URL"` // the git resource calls it uri, so we do it, too
+	PrivateKey string   `json:"private_key"`
+	KnownHosts string   `json:"known_hosts"`
+	Username   string   `json:"username"`
+	Password   string   `json:"password"`
+	Branch     string   `json:"branch"`
+	Path       string   `json:"path" validate:"required_without_all=Paths URL,omitempty,filepath"`
+	Paths      []string `json:"paths" validate:"omitempty,dive,required"` // paths or globs of further calendars; with a url, only if it is a directory
+
+	// Instead of a git repository, the calendar may be downloaded from URL (http, https) or read from it (file)
+	URL     string            `json:"url" validate:"omitempty,url"`
+	Token   string            `json:"token"`    // bearer token for URL; username and password are used for basic auth
+	CACerts string            `json:"ca_certs"` // PEM bundle of certificate authorities trusted for URL, in addition to the system's
+	Headers map[string]string `json:"headers"`  // sent with every request to URL
+
+	Depth    int    `json:"depth" validate:"gte=0"` // number of commits to clone; versions beyond it are not discovered
+	Sparse   bool   `json:"sparse"`                 // check out just the calendars
+	CacheDir string `json:"cache_dir"`              // directory for a clone reused between invocations, so that only new commits are fetched
+
+	Boundaries *Boundaries `json:"boundaries"`
+
+	// Deprecated: retry_interval is a param of the get step. It used to be documented here, where it was ignored, so it
+	// is still accepted for one more release, and used unless the params have one.
+	RetryInterval Duration `json:"retry_interval"`
+
+	IncludeCredentials []IncludeCredentials `json:"include_credentials" validate:"omitempty,dive"`
+}
+
+// IncludeCredentials are used instead of the source's credentials to fetch calendars included from URI
+type IncludeCredentials struct {
+	URI        string `json:"uri" validate:"required"`
 	PrivateKey string `json:"private_key"`
 	KnownHosts string `json:"known_hosts"`
 	Username   string `json:"username"`
 	Password   string `json:"password"`
-	Branch     string `json:"branch"`
-	Path       string `json:"path" validate:"required,filepath"`
+}
+
+// Boundaries make check emit a new version whenever a window within Scope becomes active or inactive
+type Boundaries struct {
+	Scope  []string `json:"scope"`
+	Runway Duration `json:"runway"`
 }
 
 func (source Source) Auth() (auth transport.AuthMethod, err error) {
@@ -0,0 +1,104 @@
+package resource_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/homeport/freeze-calendar-resource/freeze"
+	"github.com/homeport/freeze-calendar-resource/get"
+	"github.com/homeport/freeze-calendar-resource/put"
+	"github.com/homeport/freeze-calendar-resource/resource"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v3"
+)
+
+var yamlBlock = regexp.MustCompile("(?ms)^```yaml\n(.*?)^```")
+
+// readmeExamples returns the YAML examples of the README, without the lines that just say that something was left out
+func readmeExamples() []string {
+	readme, err := os.ReadFile("../README.markdown")
+	Expect(err).ToNot(HaveOccurred())
+
+	var examples []string
+
+	for _, match := range yamlBlock.FindAllStringSubmatch(string(readme), -1) {
+		var lines []string
+
+		for line := range strings.Lines(match[1]) {
+			if strings.TrimSpace(line) != "..." {
+				lines = append(lines, line)
+			}
+		}
+
+		examples = append(examples, strings.Join(lines, ""))
+	}
+
+	return examples
+}
+
+// decodeExample decodes each source, get and put step, or the calendar found in example, and returns how many it did
+func decodeExample(example string, node any) int {
+	var decoded int
+
+	switch node := node.(type) {
+	case []any:
+		for _, element := range node {
+			decoded += decodeExample(example, element)
+		}
+	case map[string]any:
+		for _, key := range []string{"freeze_calendar", "overrides", "include"} {
+			if _, found := node[key]; found {
+				_, err := freeze.LoadCalendar(strings.NewReader(example))
+				Expect(err).ToNot(HaveOccurred(), example)
+
+				return 1
+			}
+		}
+
+		var request any
+
+		switch {
+		case node["get"] != nil:
+			request = &get.Request{}
+		case node["put"] != nil:
+			request = &put.Request{}
+		case node["source"] != nil:
+			request = &resource.Request{}
+		default:
+			return 0
+		}
+
+		fields := map[string]any{}
+
+		for _, key := range []string{"source", "params"} {
+			if node[key] != nil {
+				fields[key] = node[key]
+			}
+		}
+
+		data, err := json.Marshal(fields)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(resource.DecodeRequest(bytes.NewReader(data), request)).To(Succeed(), example)
+		decoded++
+	}
+
+	return decoded
+}
+
+var _ = Describe("README", func() {
+	It("has examples that decode", func() {
+		examples := readmeExamples()
+		Expect(examples).ToNot(BeEmpty())
+
+		for _, example := range examples {
+			var node any
+			Expect(yaml.Unmarshal([]byte(example), &node)).To(Succeed(), example)
+			Expect(decodeExample(example, node)).To(BeNumerically(">", 0), "nothing decoded in\n%s", example)
+		}
+	})
+})
@@ -0,0 +1,526 @@
+package resource
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// repositoryFetcher gets the calendar from the source's git repository; its versions are the commits that changed it
+type repositoryFetcher struct {
+	source   Source
+	progress io.Writer
+	auth     transport.AuthMethod // for the remote the destination was cloned from, i.e. none if that is the cache
+	repo     *git.Repository
+	branch   string
+}
+
+func (r *repositoryFetcher) Versions(ctx context.Context, since Version) ([]Version, billy.Filesystem, error) {
+	repo, head, err := r.history(ctx)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	headCommit, err := repo.CommitObject(head)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get commit %s: %w", head, err)
+	}
+
+	history := calendarHistory{repo: repo, source: r.source}
+	var sinceCommit *object.Commit
+
+	if since.SHA != "" {
+		auth, err := r.source.Auth()
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to build authenticator: %w", err)
+		}
+
+		sinceCommit, err = r.commit(ctx, repo, auth, plumbing.NewHash(since.SHA))
+
+		if err != nil && !errors.Is(err, plumbing.ErrObjectNotFound) {
+			return nil, nil, fmt.Errorf("unable to get commit %s: %w", since.SHA, err)
+		}
+	}
+
+	changes, reachable, err := history.since(headCommit, sinceCommit)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not walk the history: %w", err)
+	}
+
+	var versions []Version
+
+	switch {
+	case since.SHA == "":
+		versions = commitVersions(changes)
+	case reachable:
+		// "... the list should include the requested version if it is still valid" (the oldest one)
+		// from https://concourse-ci.org/implementing-resource-types.html#resource-check
+		versions = append([]Version{since}, commitVersions(changes)...)
+	default:
+		// "If your resource is unable to determine which versions are newer than the given version (e.g. if it's a git commit that was push -fed over), then the current version of your resource should be returned (i.e. the new HEAD)."
+		if sinceCommit != nil {
+			changes, _, err = history.since(headCommit, nil)
+
+			if err != nil {
+				return nil, nil, fmt.Errorf("could not walk the history: %w", err)
+			}
+		}
+
+		if len(changes) > 0 {
+			versions = commitVersions(changes[len(changes)-1:])
+		}
+	}
+
+	fs, err := r.calendarFiles(repo, head)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return versions, fs, nil
+}
+
+// commit returns the commit with the given hash. If it lies beyond the depth of a shallow clone, the clone is deepened
+// from its origin (with auth) until the commit is found or the whole history has been fetched, e.g. so that the
+// calendar can be compared with it.
+func (r *repositoryFetcher) commit(ctx context.Context, repo *git.Repository, auth transport.AuthMethod, hash plumbing.Hash) (*object.Commit, error) {
+	commit, err := repo.CommitObject(hash)
+
+	if !errors.Is(err, plumbing.ErrObjectNotFound) || r.source.Depth == 0 {
+		return commit, err
+	}
+
+	for depth := 2 * r.source.Depth; ; depth *= 2 {
+		shallow, err := repo.Storer.Shallow()
+
+		if err != nil {
+			return nil, fmt.Errorf("unable to get the boundary of the clone: %w", err)
+		}
+
+		if len(shallow) == 0 {
+			return nil, plumbing.ErrObjectNotFound // the whole history is there
+		}
+
+		err = repo.FetchContext(ctx, &git.FetchOptions{
+			Auth:     auth,
+			Progress: r.progress,
+			Depth:    depth,
+		})
+
+		if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			return nil, fmt.Errorf("unable to deepen the clone to %d commits: %w", depth, err)
+		}
+
+		deepened, err := pruneShallow(repo)
+
+		if err != nil {
+			return nil, fmt.Errorf("unable to update the boundary of the clone: %w", err)
+		}
+
+		commit, err = repo.CommitObject(hash)
+
+		if !errors.Is(err, plumbing.ErrObjectNotFound) {
+			return commit, err
+		}
+
+		if slices.Equal(shallow, deepened) {
+			return nil, plumbing.ErrObjectNotFound // nothing more to fetch
+		}
+	}
+}
+
+// pruneShallow drops the commits whose parents have been fetched from the boundary of a shallow clone and returns the
+// remaining ones. go-git only ever adds to the boundary, and git does not serve the history beyond it, e.g. from a
+// deepened cache to a clone of it.
+func pruneShallow(repo *git.Repository) ([]plumbing.Hash, error) {
+	shallow, err := repo.Storer.Shallow()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var boundary []plumbing.Hash
+
+	for _, hash := range shallow {
+		commit, err := repo.CommitObject(hash)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, parent := range commit.ParentHashes {
+			if repo.Storer.HasEncodedObject(parent) != nil {
+				boundary = append(boundary, hash)
+				break
+			}
+		}
+	}
+
+	if len(boundary) == len(shallow) {
+		return shallow, nil
+	}
+
+	return boundary, repo.Storer.SetShallow(boundary)
+}
+
+// history returns the repository to walk and the commit to start from: the cache, or an in-memory clone without a
+// worktree
+func (r *repositoryFetcher) history(ctx context.Context) (*git.Repository, plumbing.Hash, error) {
+	if r.source.CacheDir != "" {
+		repo, err := r.updateCache(ctx)
+
+		if err != nil {
+			return nil, plumbing.ZeroHash, err
+		}
+
+		head, err := repo.Head() // the remote's default branch
+
+		if r.source.Branch != "" {
+			head, err = repo.Reference(plumbing.NewBranchReferenceName(r.source.Branch), true)
+		}
+
+		if err != nil {
+			return nil, plumbing.ZeroHash, fmt.Errorf("unable to resolve branch in cache: %w", err)
+		}
+
+		return repo, head.Hash(), nil
+	}
+
+	auth, err := r.source.Auth()
+
+	if err != nil {
+		return nil, plumbing.ZeroHash, fmt.Errorf("unable to build authenticator: %w", err)
+	}
+
+	repo, err := git.CloneContext(ctx, memory.NewStorage(), nil, &git.CloneOptions{
+		URL:           r.source.URI,
+		ReferenceName: plumbing.ReferenceName(r.source.Branch),
+		Auth:          auth,
+		Progress:      r.progress,
+		Depth:         r.source.Depth,
+	})
+
+	if err != nil {
+		return nil, plumbing.ZeroHash, fmt.Errorf("unable to clone: %w", err)
+	}
+
+	head, err := repo.Head()
+
+	if err != nil {
+		return nil, plumbing.ZeroHash, fmt.Errorf("unable to determine head: %w", err)
+	}
+
+	return repo, head.Hash(), nil
+}
+
+// calendarFiles returns an in-memory filesystem with just the calendars of the given commit
+func (r *repositoryFetcher) calendarFiles(repo *git.Repository, hash plumbing.Hash) (billy.Filesystem, error) {
+	commit, err := repo.CommitObject(hash)
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to get commit %s: %w", hash, err)
+	}
+
+	files, err := commit.Files()
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to list files of commit %s: %w", hash, err)
+	}
+
+	fs := memfs.New()
+
+	err = files.ForEach(func(file *object.File) error {
+		if !r.source.IsCalendar(file.Name) {
+			return nil
+		}
+
+		contents, err := file.Contents()
+
+		if err != nil {
+			return fmt.Errorf("unable to read %s: %w", file.Name, err)
+		}
+
+		return util.WriteFile(fs, file.Name, []byte(contents), 0o644)
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to get calendars of commit %s: %w", hash, err)
+	}
+
+	return fs, nil
+}
+
+func (r *repositoryFetcher) Fetch(ctx context.Context, destination string, version Version) (Version, error) {
+	r.branch = cmp.Or(r.source.Branch, "main")
+	url := r.source.URI
+
+	var cache *git.Repository
+
+	if r.source.CacheDir != "" {
+		var err error
+		cache, err = r.updateCache(ctx)
+
+		if err != nil {
+			return Version{}, err
+		}
+
+		// the destination is cloned from (and later fetched from) the cache, which needs no authentication
+		url = r.cacheDir()
+	} else {
+		var err error
+		r.auth, err = r.source.Auth()
+
+		if err != nil {
+			return Version{}, fmt.Errorf("unable to build authenticator: %w", err)
+		}
+	}
+
+	var err error
+	r.repo, err = git.PlainCloneContext(ctx, destination, false, &git.CloneOptions{
+		URL:           url,
+		ReferenceName: plumbing.NewBranchReferenceName(r.branch),
+		SingleBranch:  true,
+		Auth:          r.auth,
+		Progress:      r.progress,
+		Depth:         r.source.Depth,
+		NoCheckout:    r.source.Sparse,
+	})
+
+	if err != nil {
+		return Version{}, fmt.Errorf("unable to clone: %w", err)
+	}
+
+	// The clone is at the latest version of the branch already, unless it is sparse.
+	if version.SHA != "" || r.source.Sparse {
+		worktree, err := r.repo.Worktree()
+
+		if err != nil {
+			return Version{}, fmt.Errorf("unable to get worktree: %w", err)
+		}
+
+		options := &git.CheckoutOptions{
+			Branch:                    plumbing.NewBranchReferenceName(r.branch),
+			Force:                     true,
+			SparseCheckoutDirectories: r.source.sparseDirectories(),
+		}
+
+		if version.SHA != "" {
+			options.Branch = ""
+			options.Hash = plumbing.NewHash(version.SHA)
+
+			// a version beyond the depth of the clone needs to be fetched first, into the cache, too
+			if cache != nil {
+				auth, err := r.source.Auth()
+
+				if err != nil {
+					return Version{}, fmt.Errorf("unable to build authenticator: %w", err)
+				}
+
+				_, err = r.commit(ctx, cache, auth, options.Hash)
+
+				if err != nil && !errors.Is(err, plumbing.ErrObjectNotFound) {
+					return Version{}, fmt.Errorf("unable to get commit %s: %w", version.SHA, err)
+				}
+			}
+
+			_, err = r.commit(ctx, r.repo, r.auth, options.Hash)
+
+			if err != nil && !errors.Is(err, plumbing.ErrObjectNotFound) {
+				return Version{}, fmt.Errorf("unable to get commit %s: %w", version.SHA, err)
+			}
+		}
+
+		err = worktree.Checkout(options)
+
+		if err != nil {
+			return Version{}, fmt.Errorf("unable to checkout %s: %w", cmp.Or(version.SHA, r.branch), err)
+		}
+	}
+
+	return r.head()
+}
+
+func (r *repositoryFetcher) Update(ctx context.Context) (Version, error) {
+	if r.source.CacheDir != "" {
+		_, err := r.updateCache(ctx)
+
+		if err != nil {
+			return Version{}, err
+		}
+	}
+
+	err := r.repo.FetchContext(ctx, &git.FetchOptions{
+		Auth:     r.auth,
+		Progress: r.progress,
+		Depth:    r.source.Depth,
+	})
+
+	if err == git.NoErrAlreadyUpToDate {
+		return r.head() // already at the latest commit
+	}
+
+	if err != nil {
+		return Version{}, fmt.Errorf("fetch failed: %w", err)
+	}
+
+	remoteHead, err := r.repo.Reference(plumbing.NewRemoteReferenceName("origin", r.branch), true)
+
+	if err != nil {
+		return Version{}, fmt.Errorf("unable to resolve reference to branch %s: %w", r.branch, err)
+	}
+
+	worktree, err := r.repo.Worktree()
+
+	if err != nil {
+		return Version{}, fmt.Errorf("unable to get worktree: %w", err)
+	}
+
+	err = worktree.ResetSparsely(&git.ResetOptions{
+		Commit: remoteHead.Hash(),
+		Mode:   git.HardReset,
+	}, r.source.sparseDirectories())
+
+	if err != nil {
+		return Version{}, fmt.Errorf("resetting the workspace failed: %w", err)
+	}
+
+	return r.head()
+}
+
+func (r *repositoryFetcher) head() (Version, error) {
+	head, err := r.repo.Head()
+
+	if err != nil {
+		return Version{}, fmt.Errorf("unable to determine head: %w", err)
+	}
+
+	return Version{SHA: head.Hash().String()}, nil
+}
+
+// cacheDir returns the directory within the source's cache_dir that holds the repository
+func (r *repositoryFetcher) cacheDir() string {
+	sum := sha256.Sum256([]byte(r.source.URI))
+
+	return filepath.Join(r.source.CacheDir, hex.EncodeToString(sum[:8]))
+}
+
+// updateCache fetches the latest changes into the cache, a bare mirror of the repository's branches. On first use,
+// the cache is created with the remote's default branch as its HEAD.
+func (r *repositoryFetcher) updateCache(ctx context.Context) (*git.Repository, error) {
+	auth, err := r.source.Auth()
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to build authenticator: %w", err)
+	}
+
+	dir := r.cacheDir()
+	repo, err := git.PlainOpen(dir)
+
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		repo, err = r.initCache(ctx, dir, auth)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to open cache %s: %w", dir, err)
+	}
+
+	err = repo.FetchContext(ctx, &git.FetchOptions{
+		Auth:     auth,
+		Progress: r.progress,
+		Depth:    r.source.Depth,
+		Force:    true, // the mirror follows force-pushes
+	})
+
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil, fmt.Errorf("unable to fetch into cache %s: %w", dir, err)
+	}
+
+	return repo, nil
+}
+
+func (r *repositoryFetcher) initCache(ctx context.Context, dir string, auth transport.AuthMethod) (*git.Repository, error) {
+	repo, err := git.PlainInit(dir, true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	remote, err := repo.CreateRemote(&config.RemoteConfig{
+		Name:  "origin",
+		URLs:  []string{r.source.URI},
+		Fetch: []config.RefSpec{"+refs/heads/*:refs/heads/*"},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	refs, err := remote.ListContext(ctx, &git.ListOptions{Auth: auth})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ref := range refs {
+		if ref.Name() == plumbing.HEAD && ref.Type() == plumbing.SymbolicReference {
+			err = repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, ref.Target()))
+
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return repo, nil
+}
+
+// commitVersions returns the versions of the given commits, in the same order
+func commitVersions(commits []*object.Commit) []Version {
+	versions := make([]Version, len(commits))
+
+	for i, commit := range commits {
+		versions[i] = Version{SHA: commit.Hash.String()}
+	}
+
+	return versions
+}
+
+// sparseDirectories returns the prefixes of the source's calendar paths (up to the first glob) if the source is sparse
+func (source Source) sparseDirectories() []string {
+	if !source.Sparse {
+		return nil
+	}
+
+	var prefixes []string
+
+	for _, pattern := range source.CalendarPaths() {
+		if i := strings.IndexAny(pattern, "*?[\\"); i >= 0 {
+			pattern = pattern[:i]
+		}
+
+		prefixes = append(prefixes, pattern)
+	}
+
+	return prefixes
+}
@@ -0,0 +1,245 @@
+package resource_test
+
+import (
+	"os"
+	"path"
+	"time"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/homeport/freeze-calendar-resource/resource"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Repository fetcher", func() {
+	var (
+		err      error
+		origin   string
+		worktree *git.Worktree
+		commits  []plumbing.Hash
+		source   resource.Source
+	)
+
+	commit := func(name, content string) plumbing.Hash {
+		Expect(util.WriteFile(worktree.Filesystem, name, []byte(content), 0o644)).To(Succeed())
+		_, err := worktree.Add(name)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		hash, err := worktree.Commit("Change "+name, &git.CommitOptions{
+			Author: &object.Signature{Name: "Testbild Tester", Email: "testbild.tester@example.org", When: time.Now()},
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		return hash
+	}
+
+	BeforeEach(func() {
+		origin = path.Join(GinkgoT().TempDir(), "remote")
+
+		repo, err := git.PlainInitWithOptions(origin, &git.PlainInitOptions{
+			InitOptions: git.InitOptions{DefaultBranch: plumbing.Main},
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		worktree, err = repo.Worktree()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		commits = []plumbing.Hash{
+			commit("calendars/freeze.yaml", "freeze_calendar: []\n"),
+			commit("config/other.yaml", "other: config\n"),
+			commit("calendars/freeze.yaml", "freeze_calendar: [] # changed\n"),
+		}
+
+		source = resource.Source{URI: origin, Path: "calendars/freeze.yaml"}
+	})
+
+	Describe("versions", func() {
+		var (
+			versions []resource.Version
+			fs       billy.Filesystem
+			since    resource.Version
+		)
+
+		BeforeEach(func() {
+			since = resource.Version{}
+		})
+
+		JustBeforeEach(func(ctx SpecContext) {
+			var fetcher resource.Fetcher
+			fetcher, err = source.Fetcher(GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			versions, fs, err = fetcher.Versions(ctx, since)
+		})
+
+		It("are the commits that changed the calendar", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(versions).To(Equal([]resource.Version{{SHA: commits[0].String()}, {SHA: commits[2].String()}}))
+		})
+
+		It("come with just the calendar files", func() {
+			Expect(util.ReadFile(fs, "calendars/freeze.yaml")).To(BeEquivalentTo("freeze_calendar: [] # changed\n"))
+			Expect(fs.Stat("config/other.yaml")).Error().To(MatchError(os.ErrNotExist))
+		})
+
+		Context("with a depth", func() {
+			BeforeEach(func() {
+				source.Depth = 1
+			})
+
+			It("discovers just the commits within that depth", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(versions).To(Equal([]resource.Version{{SHA: commits[2].String()}}))
+			})
+
+			Context("and a head that did not change the calendar", func() {
+				BeforeEach(func() {
+					since = resource.Version{SHA: commits[2].String()}
+					commit("config/other.yaml", "other: changed config\n")
+				})
+
+				It("keeps the given version", func() {
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(versions).To(Equal([]resource.Version{since}))
+				})
+			})
+
+			Context("and a version beyond it", func() {
+				var latest plumbing.Hash
+
+				BeforeEach(func() {
+					since = resource.Version{SHA: commits[0].String()}
+					commit("config/other.yaml", "other: changed config\n")
+					latest = commit("calendars/freeze.yaml", "freeze_calendar: [] # changed again\n")
+					commit("config/other.yaml", "other: changed config again\n")
+				})
+
+				It("discovers the commits that changed the calendar since then", func() {
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(versions).To(Equal([]resource.Version{since, {SHA: commits[2].String()}, {SHA: latest.String()}}))
+				})
+			})
+		})
+
+		Context("with a cache", func() {
+			BeforeEach(func() {
+				source.CacheDir = GinkgoT().TempDir()
+			})
+
+			It("has the same versions", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(versions).To(Equal([]resource.Version{{SHA: commits[0].String()}, {SHA: commits[2].String()}}))
+			})
+
+			It("keeps a clone in the cache", func() {
+				Expect(os.ReadDir(source.CacheDir)).To(HaveLen(1))
+			})
+
+			It("fetches new commits into the cache", func(ctx SpecContext) {
+				latest := commit("calendars/freeze.yaml", "freeze_calendar: [] # changed again\n")
+
+				fetcher, err := source.Fetcher(GinkgoWriter)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				versions, fs, err := fetcher.Versions(ctx, resource.Version{})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(versions).To(HaveLen(3))
+				Expect(versions[2].SHA).To(Equal(latest.String()))
+				Expect(util.ReadFile(fs, "calendars/freeze.yaml")).To(BeEquivalentTo("freeze_calendar: [] # changed again\n"))
+			})
+		})
+	})
+
+	Describe("fetching", func() {
+		var (
+			destination string
+			requested   resource.Version
+			version     resource.Version
+			fetcher     resource.Fetcher
+		)
+
+		BeforeEach(func() {
+			destination = path.Join(GinkgoT().TempDir(), "destination")
+			requested = resource.Version{}
+		})
+
+		JustBeforeEach(func(ctx SpecContext) {
+			fetcher, err = source.Fetcher(GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			version, err = fetcher.Fetch(ctx, destination, requested)
+		})
+
+		It("clones the latest commit", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(version.SHA).To(Equal(commits[2].String()))
+			Expect(path.Join(destination, "config/other.yaml")).To(BeAnExistingFile())
+		})
+
+		Context("with a depth and a version beyond it", func() {
+			BeforeEach(func() {
+				source.Depth = 1
+				requested = resource.Version{SHA: commits[0].String()}
+			})
+
+			It("deepens the clone to check out the version", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(version.SHA).To(Equal(commits[0].String()))
+				Expect(os.ReadFile(path.Join(destination, "calendars/freeze.yaml"))).To(BeEquivalentTo("freeze_calendar: []\n"))
+			})
+
+			Context("and a cache", func() {
+				BeforeEach(func() {
+					source.CacheDir = GinkgoT().TempDir()
+				})
+
+				It("deepens the cache, too", func() {
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(version.SHA).To(Equal(commits[0].String()))
+					Expect(os.ReadFile(path.Join(destination, "calendars/freeze.yaml"))).To(BeEquivalentTo("freeze_calendar: []\n"))
+				})
+			})
+		})
+
+		Context("sparsely", func() {
+			BeforeEach(func() {
+				source.Sparse = true
+			})
+
+			It("checks out just the calendar", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(os.ReadFile(path.Join(destination, "calendars/freeze.yaml"))).To(BeEquivalentTo("freeze_calendar: [] # changed\n"))
+				Expect(path.Join(destination, "config/other.yaml")).ToNot(BeAnExistingFile())
+			})
+
+			It("keeps it that way when updating", func(ctx SpecContext) {
+				latest := commit("config/other.yaml", "other: changed\n")
+
+				Expect(fetcher.Update(ctx)).To(Equal(resource.Version{SHA: latest.String()}))
+				Expect(path.Join(destination, "config/other.yaml")).ToNot(BeAnExistingFile())
+			})
+		})
+
+		Context("with a cache", func() {
+			BeforeEach(func() {
+				source.CacheDir = GinkgoT().TempDir()
+			})
+
+			It("clones the latest commit", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(version.SHA).To(Equal(commits[2].String()))
+			})
+
+			It("updates through the cache", func(ctx SpecContext) {
+				latest := commit("calendars/freeze.yaml", "freeze_calendar: [] # changed again\n")
+
+				Expect(fetcher.Update(ctx)).To(Equal(resource.Version{SHA: latest.String()}))
+				Expect(os.ReadFile(path.Join(destination, "calendars/freeze.yaml"))).To(BeEquivalentTo("freeze_calendar: [] # changed again\n"))
+			})
+		})
+	})
+})
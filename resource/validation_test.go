@@ -75,4 +75,43 @@ var _ = Describe("Source Validation", func() {
 			Expect(err).To(MatchError(ContainSubstring("validation for 'URI' failed")))
 		})
 	})
+
+	Context("paths instead of path", func() {
+		BeforeEach(func() {
+			source = resource.Source{
+				URI:   "git@github.com:homeport/freeze-calendar-resource",
+				Paths: []string{"calendars/*.yaml"},
+			}
+		})
+
+		It("works", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("url instead of uri", func() {
+		BeforeEach(func() {
+			source = resource.Source{
+				URL: "https://cab.example.com/freeze-calendar.yaml",
+			}
+		})
+
+		It("works", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("both uri and url", func() {
+		BeforeEach(func() {
+			source = resource.Source{
+				URI:  "git@github.com:homeport/freeze-calendar-resource",
+				URL:  "https://cab.example.com/freeze-calendar.yaml",
+				Path: "freeze-calendar.yaml",
+			}
+		})
+
+		It("has the expected error", func() {
+			Expect(err).To(MatchError(ContainSubstring("validation for 'URI' failed")))
+		})
+	})
 })
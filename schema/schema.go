@@ -0,0 +1,298 @@
+// Package schema generates JSON Schemas (draft 2020-12) of the calendar format and of the resource's source and params
+// from the Go types and their validate tags, so that editors can complete and check them.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/homeport/freeze-calendar-resource/freeze"
+	"github.com/homeport/freeze-calendar-resource/keys"
+	"github.com/homeport/freeze-calendar-resource/lgr"
+	"github.com/homeport/freeze-calendar-resource/resource"
+	"github.com/spf13/cobra"
+)
+
+// Schema is a JSON Schema; maps are encoded with sorted keys, so that the result is stable
+type Schema map[string]any
+
+const (
+	timestampPattern = `^\d{4}-\d{1,2}-\d{1,2}([Tt ]\d{1,2}:\d{1,2}(:\d{1,2}(\.\d+)?)?([Zz]|[+-]\d{2}:\d{2})?)?$`
+	durationPattern  = `^([-+]?((\d+(\.\d*)?|\.\d+)(ns|us|µs|ms|s|m|h))+|0)$`
+)
+
+// special describes types that are not encoded as their Go structure
+var special = map[reflect.Type]Schema{
+	reflect.TypeFor[time.Time]():         {"type": "string", "pattern": timestampPattern, "description": "timestamp, e.g. 2023-12-24T06:00:00Z; without offset, it is resolved against the time zone"},
+	reflect.TypeFor[time.Duration]():     {"type": "string", "pattern": durationPattern, "description": "duration, e.g. 63h or 1h30m"},
+	reflect.TypeFor[resource.Duration](): {"type": "string", "pattern": durationPattern, "description": "duration, e.g. 30m or 1h30m"},
+	reflect.TypeFor[freeze.Recurrence](): {"type": "string", "pattern": `^(RRULE:)?([A-Z]+=[^;]*;?)+$`, "description": "iCalendar recurrence rule, e.g. FREQ=WEEKLY;BYDAY=FR"},
+	reflect.TypeFor[resource.Mode]():     {"type": "string", "enum": resource.Modi.Values()},
+	reflect.TypeFor[resource.Outcome]():  {"type": "string", "enum": resource.Outcomes.Values()},
+	reflect.TypeFor[lgr.Format]():        {"type": "string", "enum": lgr.Formats.Values()},
+	reflect.TypeFor[map[string]string](): {"type": "object", "additionalProperties": Schema{"type": "string"}},
+}
+
+// inexpressible are validations that JSON Schema cannot express (or only by patterns not worth maintaining)
+var inexpressible = []string{"omitempty", "filepath", "timezone"}
+
+// schemas are the types a schema is generated for, with the tag their keys are taken from
+var schemas = map[string]struct {
+	title string
+	typ   reflect.Type
+	tag   string
+}{
+	"calendar": {"Freeze Calendar", reflect.TypeFor[freeze.Calendar](), "yaml"},
+	"source":   {"Source of the Freeze Calendar Resource", reflect.TypeFor[resource.Source](), "json"},
+	"params":   {"Params of the get step of the Freeze Calendar Resource", reflect.TypeFor[resource.Params](), "json"},
+}
+
+// Names are the names of the schemas that Generate knows, in alphabetical order
+func Names() []string {
+	var names []string
+
+	for name := range schemas {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+	return names
+}
+
+func RunE(cmd *cobra.Command, args []string) error {
+	result, err := Generate(args[0])
+
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(cmd.OutOrStdout())
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+
+	return encoder.Encode(result)
+}
+
+// Generate returns the schema with the given name, e.g. calendar
+func Generate(name string) (Schema, error) {
+	s, found := schemas[name]
+
+	if !found {
+		return nil, fmt.Errorf("unknown schema '%s'; known ones are %s", name, strings.Join(Names(), ", "))
+	}
+
+	result, err := For(s.typ, s.tag)
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate schema of %s: %w", name, err)
+	}
+
+	result["title"] = s.title
+	return result, nil
+}
+
+// For generates the schema of a struct type whose keys are given by tag (yaml or json). Validations that the schema
+// cannot express are ignored, but only the ones known to be inexpressible; others are an error, so that no validation
+// is silently missing from the schema.
+func For(typ reflect.Type, tag string) (Schema, error) {
+	g := generator{tag: tag, definitions: Schema{}}
+	root, err := g.object(typ)
+
+	if err != nil {
+		return nil, err
+	}
+
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+
+	if len(g.definitions) > 0 {
+		root["$defs"] = g.definitions
+	}
+
+	return root, nil
+}
+
+type generator struct {
+	tag         string
+	definitions Schema
+}
+
+// of returns the schema of typ, applying the given validations
+func (g generator) of(typ reflect.Type, validations []string) (Schema, error) {
+	var result Schema
+
+	switch {
+	case special[typ] != nil:
+		result = clone(special[typ])
+	case typ.Kind() == reflect.Pointer:
+		return g.of(typ.Elem(), validations)
+	case typ.Kind() == reflect.Struct:
+		if _, defined := g.definitions[typ.Name()]; !defined {
+			g.definitions[typ.Name()] = Schema{} // placeholder, in case of recursion
+
+			definition, err := g.object(typ)
+
+			if err != nil {
+				return nil, err
+			}
+
+			g.definitions[typ.Name()] = definition
+		}
+
+		result = Schema{"$ref": "#/$defs/" + typ.Name()}
+	case typ.Kind() == reflect.Slice:
+		dive := slices.Index(validations, "dive")
+		var itemValidations []string
+
+		if dive >= 0 {
+			validations, itemValidations = validations[:dive], validations[dive+1:]
+		}
+
+		items, err := g.of(typ.Elem(), itemValidations)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = Schema{"type": "array", "items": items}
+	case typ.Kind() == reflect.String:
+		result = Schema{"type": "string"}
+	case typ.Kind() == reflect.Bool:
+		result = Schema{"type": "boolean"}
+	case typ.Kind() >= reflect.Int && typ.Kind() <= reflect.Uint64:
+		result = Schema{"type": "integer"}
+	default:
+		return nil, fmt.Errorf("unsupported type %s", typ)
+	}
+
+	for _, validation := range validations {
+		name, param, _ := strings.Cut(validation, "=")
+
+		switch {
+		case name == "required" && result["type"] == "string" && result["pattern"] == nil && result["enum"] == nil:
+			result["minLength"] = 1
+		case name == "required":
+			continue // present, as required by the object
+		case name == "url":
+			result["format"] = "uri"
+		case (name == "gt" || name == "gte") && result["type"] == "integer":
+			bound, err := strconv.Atoi(param)
+
+			if err != nil {
+				return nil, fmt.Errorf("invalid bound in validation '%s': %w", validation, err)
+			}
+
+			result[map[string]string{"gt": "exclusiveMinimum", "gte": "minimum"}[name]] = bound
+		case (name == "gt" || name == "gte") && result["pattern"] == durationPattern:
+			continue // positive durations would need a pattern of their own
+		case slices.Contains(inexpressible, name):
+			continue
+		default:
+			return nil, fmt.Errorf("unsupported validation '%s' of type %s", validation, typ)
+		}
+	}
+
+	return result, nil
+}
+
+// object returns the schema of a struct type; the object-level validations of its fields (e.g. required_without) go
+// into its required and allOf keywords
+func (g generator) object(typ reflect.Type) (Schema, error) {
+	fields := keys.Fields(typ, g.tag)
+	properties := Schema{}
+	var required []string
+	var constraints []Schema
+
+	keyOf := func(fieldName string) (string, error) {
+		for _, field := range fields {
+			if field.Name == fieldName {
+				return field.Key, nil
+			}
+		}
+
+		return "", fmt.Errorf("unknown field %s of %s", fieldName, typ)
+	}
+
+	for _, field := range fields {
+		var validations []string
+
+		if tag := field.Tag.Get("validate"); tag != "" {
+			validations = strings.Split(tag, ",")
+		}
+
+		var rest []string
+
+		for i, validation := range validations {
+			if validation == "dive" {
+				rest = append(rest, validations[i:]...)
+				break
+			}
+
+			name, param, _ := strings.Cut(validation, "=")
+
+			switch name {
+			case "required":
+				required = append(required, field.Key)
+				rest = append(rest, validation) // non-empty, too
+			case "required_without", "required_without_all":
+				alternatives := []Schema{{"required": []string{field.Key}}}
+
+				for _, other := range strings.Fields(param) {
+					key, err := keyOf(other)
+
+					if err != nil {
+						return nil, err
+					}
+
+					alternatives = append(alternatives, Schema{"required": []string{key}})
+				}
+
+				constraints = append(constraints, Schema{"anyOf": alternatives})
+			case "excluded_with":
+				key, err := keyOf(param)
+
+				if err != nil {
+					return nil, err
+				}
+
+				constraints = append(constraints, Schema{"not": Schema{"required": []string{field.Key, key}}})
+			default:
+				rest = append(rest, validation)
+			}
+		}
+
+		property, err := g.of(field.Type, rest)
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", field.Key, err)
+		}
+
+		properties[field.Key] = property
+	}
+
+	result := Schema{"type": "object", "properties": properties, "additionalProperties": false}
+
+	if len(required) > 0 {
+		result["required"] = required
+	}
+
+	if len(constraints) > 0 {
+		result["allOf"] = constraints
+	}
+
+	return result, nil
+}
+
+func clone(s Schema) Schema {
+	result := Schema{}
+
+	for key, value := range s {
+		result[key] = value
+	}
+
+	return result
+}
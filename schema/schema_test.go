@@ -0,0 +1,96 @@
+package schema_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/homeport/freeze-calendar-resource/schema"
+)
+
+var _ = Describe("Schema", func() {
+	DescribeTable("is published as generated from the Go types",
+		func(name string) {
+			var stdout strings.Builder
+			cmd := &cobra.Command{}
+			cmd.SetOut(&stdout)
+			cmd.SetContext(context.Background())
+			Expect(schema.RunE(cmd, []string{name})).To(Succeed())
+
+			published, err := os.ReadFile(filepath.Join("..", "schemas", name+".json"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stdout.String()).To(Equal(string(published)), "schemas/%[1]s.json is outdated; run 'go run . schema %[1]s > schemas/%[1]s.json'", name)
+		},
+		Entry(nil, "calendar"),
+		Entry(nil, "source"),
+		Entry(nil, "params"),
+	)
+
+	It("does not know other schemas", func() {
+		_, err := schema.Generate("pipeline")
+		Expect(err).To(MatchError("unknown schema 'pipeline'; known ones are calendar, params, source"))
+	})
+
+	Describe("of a type", func() {
+		type Window struct {
+			Name     string        `json:"name" validate:"required"`
+			Start    time.Time     `json:"start"`
+			End      *time.Time    `json:"end,omitempty" validate:"required_without=Duration,excluded_with=Duration"`
+			Duration time.Duration `json:"duration,omitempty"`
+			Retries  int           `json:"retries" validate:"gte=0"`
+			Tags     []string      `json:"tags" validate:"dive,required"`
+			Ignored  string        `json:"-"`
+		}
+
+		var (
+			result schema.Schema
+			err    error
+		)
+
+		BeforeEach(func() {
+			result, err = schema.For(reflect.TypeFor[Window](), "json")
+		})
+
+		It("has a property for each key", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result["properties"]).To(HaveKey("name"))
+			Expect(result["properties"]).To(HaveKey("start"))
+			Expect(result["properties"]).To(HaveKey("end"))
+			Expect(result["properties"]).To(HaveKey("duration"))
+			Expect(result["properties"]).To(HaveKey("retries"))
+			Expect(result["properties"]).To(HaveKey("tags"))
+			Expect(result["properties"]).ToNot(HaveKey("Ignored"))
+			Expect(result["additionalProperties"]).To(BeFalse())
+		})
+
+		It("expresses the validations", func() {
+			properties := result["properties"].(schema.Schema)
+
+			Expect(result["required"]).To(Equal([]string{"name"}))
+			Expect(properties["name"]).To(HaveKeyWithValue("minLength", 1))
+			Expect(properties["retries"]).To(HaveKeyWithValue("minimum", 0))
+			Expect(properties["tags"]).To(HaveKeyWithValue("items", schema.Schema{"type": "string", "minLength": 1}))
+			Expect(result["allOf"]).To(Equal([]schema.Schema{
+				{"anyOf": []schema.Schema{{"required": []string{"end"}}, {"required": []string{"duration"}}}},
+				{"not": schema.Schema{"required": []string{"end", "duration"}}},
+			}))
+		})
+	})
+
+	It("fails on validations it cannot express", func() {
+		type Override struct {
+			Approver string `json:"approver" validate:"required,email"`
+		}
+
+		_, err := schema.For(reflect.TypeFor[Override](), "json")
+		Expect(err).To(MatchError("approver: unsupported validation 'email' of type string"))
+	})
+})
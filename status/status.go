@@ -0,0 +1,90 @@
+package status
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/homeport/freeze-calendar-resource/freeze"
+	"github.com/spf13/cobra"
+)
+
+// ExitCodeFrozen is the exit code of the status command if the calendar freezes the scope; other failures exit with 1
+const ExitCodeFrozen = 3
+
+// Scope is the scope to evaluate the calendar for, like the scope param of get; empty means all scopes
+var Scope []string
+
+// Runway is the time needed for a deployment, like the runway param of get
+var Runway time.Duration
+
+// At is the time to evaluate the calendar at; zero means now
+var At time.Time
+
+// IncludePaths maps URIs of included repositories to local directories, as for lint
+var IncludePaths map[string]string
+
+// FrozenError is returned if windows are active for the scope and no override applies
+type FrozenError struct {
+	Windows []freeze.Window
+}
+
+func (e FrozenError) Error() string {
+	return fmt.Sprintf("frozen by %d windows", len(e.Windows))
+}
+
+// ExitCode makes the command exit with ExitCodeFrozen, so that scripts can tell a freeze from a failure
+func (e FrozenError) ExitCode() int {
+	return ExitCodeFrozen
+}
+
+func RunE(cmd *cobra.Command, args []string) error {
+	calendar, err := freeze.LoadFile(args[0], freeze.LocalFetcher(IncludePaths))
+
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+
+	at := At
+
+	if at.IsZero() {
+		at = time.Now().Truncate(time.Second)
+	}
+
+	statuses := calendar.Evaluate(at, Runway, Scope)
+	frozen := statuses.Frozen()
+
+	if len(frozen) == 0 {
+		fmt.Fprintf(out, "Not frozen at %s for %s.\n", at, describe(Scope))
+
+		if next, found := statuses.Next(); found {
+			fmt.Fprintf(out, "Next window: %s\n", next)
+		}
+
+		return nil
+	}
+
+	fmt.Fprintf(out, "Frozen at %s for %s by %d windows:\n", at, describe(Scope), len(frozen))
+
+	for _, w := range frozen {
+		fmt.Fprintln(out, w)
+	}
+
+	// as in get, only overrides that apply to any build count, since there is no build to match
+	if o, found := calendar.Override(at, Scope, freeze.Build{}); found {
+		fmt.Fprintf(out, "But there is an override %s\n", o)
+		return nil
+	}
+
+	return FrozenError{Windows: frozen}
+}
+
+func describe(scope []string) string {
+	if len(scope) == 0 {
+		return "all scopes"
+	}
+
+	return "scope " + strings.Join(scope, ", ")
+}
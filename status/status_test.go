@@ -0,0 +1,130 @@
+package status_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/homeport/freeze-calendar-resource/status"
+)
+
+var _ = Describe("Status", func() {
+	var (
+		err      error
+		calendar string
+		stdout   strings.Builder
+	)
+
+	BeforeEach(func() {
+		calendar = path.Join(GinkgoT().TempDir(), "calendar.yaml")
+		stdout = strings.Builder{}
+
+		Expect(os.WriteFile(calendar, []byte(`freeze_calendar:
+  - name: Oktoberfest
+    starts_at: 2023-10-11T16:00:00Z
+    ends_at: 2023-10-12T00:00:00Z
+    scope: [eu-de]
+  - name: Thanksgiving
+    starts_at: 2023-11-23T00:00:00Z
+    ends_at: 2023-11-24T00:00:00Z
+    scope: [us]
+overrides:
+  - reason: Hotfix for INC-4711
+    approver: jane.doe@example.com
+    expires_at: 2023-10-11T20:00:00Z
+    scope: [eu-de]
+`), 0o644)).To(Succeed())
+
+		status.At = time.Date(2023, time.October, 11, 21, 0, 0, 0, time.UTC)
+
+		DeferCleanup(func() {
+			status.At = time.Time{}
+			status.Scope = nil
+			status.Runway = 0
+		})
+	})
+
+	JustBeforeEach(func() {
+		cmd := &cobra.Command{}
+		cmd.SetOut(&stdout)
+		cmd.SetContext(context.Background())
+
+		err = status.RunE(cmd, []string{calendar})
+	})
+
+	It("fails with a distinct exit code", func() {
+		var frozen status.FrozenError
+		Expect(errors.As(err, &frozen)).To(BeTrue())
+		Expect(frozen.ExitCode()).To(Equal(status.ExitCodeFrozen))
+		Expect(frozen.Windows).To(HaveLen(1))
+	})
+
+	It("prints the active windows", func() {
+		Expect(stdout.String()).To(HavePrefix("Frozen at 2023-10-11 21:00:00 +0000 UTC for all scopes by 1 windows:\nOktoberfest from"))
+	})
+
+	Context("for another scope", func() {
+		BeforeEach(func() {
+			status.Scope = []string{"us"}
+		})
+
+		It("succeeds", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("prints the next window", func() {
+			Expect(stdout.String()).To(HavePrefix("Not frozen at 2023-10-11 21:00:00 +0000 UTC for scope us.\nNext window: Thanksgiving from"))
+		})
+	})
+
+	Context("before the window", func() {
+		BeforeEach(func() {
+			status.At = time.Date(2023, time.October, 11, 15, 0, 0, 0, time.UTC)
+		})
+
+		It("succeeds", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		Context("with a runway reaching into it", func() {
+			BeforeEach(func() {
+				status.Runway = 2 * time.Hour
+			})
+
+			It("is frozen", func() {
+				Expect(err).To(BeAssignableToTypeOf(status.FrozenError{}))
+			})
+		})
+	})
+
+	Context("while an override applies", func() {
+		BeforeEach(func() {
+			status.At = time.Date(2023, time.October, 11, 17, 0, 0, 0, time.UTC)
+			status.Scope = []string{"eu-de"}
+		})
+
+		It("succeeds", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stdout.String()).To(ContainSubstring("But there is an override approved by jane.doe@example.com"))
+		})
+	})
+
+	Context("with a calendar that cannot be loaded", func() {
+		BeforeEach(func() {
+			calendar = path.Join(GinkgoT().TempDir(), "missing.yaml")
+		})
+
+		It("fails with an ordinary error", func() {
+			Expect(err).To(MatchError(ContainSubstring("unable to read calendar file")))
+			Expect(err).ToNot(BeAssignableToTypeOf(status.FrozenError{}))
+		})
+	})
+})